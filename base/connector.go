@@ -1,11 +1,16 @@
 package base
 
-import "github.com/urandom/graph"
+import (
+	"sync"
 
-// Connector is a base implementation of a graph.Connector
+	"github.com/urandom/graph"
+)
+
+// Connector is a base implementation of a graph.Connector. Its targets may be
+// changed and read from several goroutines at once
 type Connector struct {
-	targetLinker    graph.Linker
-	targetConnector graph.Connector
+	mu      sync.RWMutex
+	targets []graph.Endpoint
 
 	kind graph.ConnectorType
 	name graph.ConnectorName
@@ -43,16 +48,33 @@ func newConnector(kind graph.ConnectorType, name ...graph.ConnectorName) *Connec
 	return &c
 }
 
-func (c Connector) Type() graph.ConnectorType {
+func (c *Connector) Type() graph.ConnectorType {
 	return c.kind
 }
 
-func (c Connector) Name() graph.ConnectorName {
+func (c *Connector) Name() graph.ConnectorName {
 	return c.name
 }
 
-func (c Connector) Target() (graph.Linker, graph.Connector) {
-	return c.targetLinker, c.targetConnector
+func (c *Connector) Target() (graph.Linker, graph.Connector) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.targets) == 0 {
+		return nil, nil
+	}
+
+	return c.targets[0].Linker, c.targets[0].Connector
+}
+
+func (c *Connector) Targets() []graph.Endpoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	targets := make([]graph.Endpoint, len(c.targets))
+	copy(targets, c.targets)
+
+	return targets
 }
 
 func (c *Connector) Connect(target graph.Linker, connector graph.Connector) error {
@@ -64,12 +86,46 @@ func (c *Connector) Connect(target graph.Linker, connector graph.Connector) erro
 		return graph.ErrSameConnectorType
 	}
 
-	c.targetLinker = target
-	c.targetConnector = connector
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.kind == graph.InputType {
+		c.targets = nil
+	}
+
+	for _, t := range c.targets {
+		if t.Connector == connector {
+			return nil
+		}
+	}
+
+	c.targets = append(c.targets, graph.Endpoint{Linker: target, Connector: connector})
 	return nil
 }
 
-func (c *Connector) Disconnect() {
-	c.targetLinker = nil
-	c.targetConnector = nil
+func (c *Connector) Disconnect(target ...graph.Connector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(target) == 0 {
+		c.targets = nil
+		return
+	}
+
+	targets := c.targets[:0]
+	for _, t := range c.targets {
+		keep := true
+		for _, tc := range target {
+			if t.Connector == tc {
+				keep = false
+				break
+			}
+		}
+
+		if keep {
+			targets = append(targets, t)
+		}
+	}
+
+	c.targets = targets
 }
@@ -76,3 +76,41 @@ func TestConnector(t *testing.T) {
 		t.Fatalf("Expected %v, got %v\n", graph.ErrSameConnectorType, err)
 	}
 }
+
+func TestConnectorTargets(t *testing.T) {
+	c := NewOutputConnector()
+
+	l1, l2 := NewLinker(), NewLinker()
+	c.Connect(l1, l1.Connector(graph.InputName))
+	c.Connect(l2, l2.Connector(graph.InputName))
+	c.Connect(l2, l2.Connector(graph.InputName))
+
+	targets := c.Targets()
+	if len(targets) != 2 {
+		t.Fatalf("Expected %v targets, got %v\n", 2, len(targets))
+	}
+
+	if targets[0].Linker != l1 || targets[1].Linker != l2 {
+		t.Fatalf("Unexpected targets %v\n", targets)
+	}
+
+	if n, _ := c.Target(); n != l1 {
+		t.Fatalf("Expected %v as the first target, got %v\n", l1, n)
+	}
+
+	c.Disconnect(l1.Connector(graph.InputName))
+
+	targets = c.Targets()
+	if len(targets) != 1 || targets[0].Linker != l2 {
+		t.Fatalf("Unexpected targets %v\n", targets)
+	}
+
+	in := NewInputConnector()
+	in.Connect(l1, l1.Connector(graph.OutputName, graph.OutputType))
+	in.Connect(l2, l2.Connector(graph.OutputName, graph.OutputType))
+
+	targets = in.Targets()
+	if len(targets) != 1 || targets[0].Linker != l2 {
+		t.Fatalf("Unexpected targets %v\n", targets)
+	}
+}
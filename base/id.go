@@ -0,0 +1,135 @@
+package base
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/urandom/graph"
+)
+
+const maxUint = ^uint64(0)
+
+// Ids below minAutoId are never handed out by the generators of this package,
+// and may be assigned to nodes by hand
+const minAutoId = uint64(^uint16(0))
+
+// IdGenerator generates the ids of the nodes created by NewNode and NewLinker.
+// It has to be safe for concurrent use, since graphs may be built from
+// several goroutines at once.
+type IdGenerator interface {
+	// NextId returns a new id
+	NextId() graph.Id
+}
+
+// IdGeneratorFunc adapts a function to the IdGenerator interface
+type IdGeneratorFunc func() graph.Id
+
+// sequentialIds returns incremental ids, starting over from its start once
+// the maximum id has been reached
+type sequentialIds struct {
+	start   uint64
+	counter atomic.Uint64
+}
+
+type randomIds struct{}
+
+type uuidIds struct{}
+
+// generatorHolder allows storing any IdGenerator in an atomic.Value, which
+// requires the stored values to be of the same concrete type
+type generatorHolder struct {
+	IdGenerator
+}
+
+var generator atomic.Value
+
+func init() {
+	generator.Store(generatorHolder{NewSequentialIdGenerator(graph.Id(minAutoId))})
+}
+
+// NewSequentialIdGenerator creates a generator of incremental ids, beginning
+// with the given one. It is the default generator, starting from the first id
+// that is not reserved for manual assignment.
+func NewSequentialIdGenerator(start graph.Id) IdGenerator {
+	g := &sequentialIds{start: uint64(start)}
+	g.counter.Store(uint64(start))
+
+	return g
+}
+
+// NewRandomIdGenerator creates a generator of random 64-bit ids, read from
+// crypto/rand. Unlike sequential ids, random ones are unlikely to collide
+// with the ids of nodes created by another process.
+func NewRandomIdGenerator() IdGenerator {
+	return randomIds{}
+}
+
+// NewUUIDIdGenerator creates a generator of ids derived from random (version
+// 4) UUIDs, by folding the 128 bits of each UUID into 64
+func NewUUIDIdGenerator() IdGenerator {
+	return uuidIds{}
+}
+
+// SetIdGenerator replaces the generator used by NewNode and NewLinker, and
+// returns the previous one. A caller-supplied function may be set by wrapping
+// it in an IdGeneratorFunc. The generator must not be nil.
+func SetIdGenerator(g IdGenerator) IdGenerator {
+	return generator.Swap(generatorHolder{g}).(generatorHolder).IdGenerator
+}
+
+func (f IdGeneratorFunc) NextId() graph.Id {
+	return f()
+}
+
+func (g *sequentialIds) NextId() graph.Id {
+	for {
+		id := g.counter.Load()
+
+		next := id + 1
+		if id == maxUint {
+			next = g.start
+		}
+
+		if g.counter.CompareAndSwap(id, next) {
+			return graph.Id(id)
+		}
+	}
+}
+
+func (g randomIds) NextId() graph.Id {
+	var b [8]byte
+
+	for {
+		readRandom(b[:])
+
+		if id := binary.BigEndian.Uint64(b[:]); id >= minAutoId {
+			return graph.Id(id)
+		}
+	}
+}
+
+func (g uuidIds) NextId() graph.Id {
+	var uuid [16]byte
+
+	for {
+		readRandom(uuid[:])
+		uuid[6] = uuid[6]&0x0f | 0x40
+		uuid[8] = uuid[8]&0x3f | 0x80
+
+		id := binary.BigEndian.Uint64(uuid[:8]) ^ binary.BigEndian.Uint64(uuid[8:])
+		if id >= minAutoId {
+			return graph.Id(id)
+		}
+	}
+}
+
+func readRandom(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("reading random bytes: " + err.Error())
+	}
+}
+
+func nextId() graph.Id {
+	return generator.Load().(generatorHolder).NextId()
+}
@@ -0,0 +1,72 @@
+package base
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/urandom/graph"
+)
+
+func TestIdGenerator(t *testing.T) {
+	const workers, count = 8, 1000
+
+	for name, g := range map[string]IdGenerator{
+		"sequential": NewSequentialIdGenerator(graph.Id(minAutoId)),
+		"random":     NewRandomIdGenerator(),
+		"uuid":       NewUUIDIdGenerator(),
+	} {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		ids := make(map[graph.Id]bool)
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < count; j++ {
+					id := g.NextId()
+
+					mu.Lock()
+					ids[id] = true
+					mu.Unlock()
+
+					if uint64(id) < minAutoId {
+						t.Errorf("%s: Expected an id of at least %v, got %v\n", name, minAutoId, id)
+					}
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if len(ids) != workers*count {
+			t.Fatalf("%s: Expected %v, got %v\n", name, workers*count, len(ids))
+		}
+	}
+}
+
+func TestSequentialIdGeneratorWrap(t *testing.T) {
+	g := NewSequentialIdGenerator(graph.Id(maxUint - 1))
+
+	for _, expected := range []graph.Id{graph.Id(maxUint - 1), graph.Id(maxUint), graph.Id(maxUint - 1)} {
+		if id := g.NextId(); id != expected {
+			t.Fatalf("Expected %v, got %v\n", expected, id)
+		}
+	}
+}
+
+func TestSetIdGenerator(t *testing.T) {
+	var next graph.Id = 1
+	prev := SetIdGenerator(IdGeneratorFunc(func() graph.Id {
+		next++
+		return next
+	}))
+	defer SetIdGenerator(prev)
+
+	for _, expected := range []graph.Id{2, 3} {
+		if id := NewLinker().Node().Id(); id != expected {
+			t.Fatalf("Expected %v, got %v\n", expected, id)
+		}
+	}
+}
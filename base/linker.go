@@ -1,6 +1,11 @@
 package base
 
-import "github.com/urandom/graph"
+import (
+	"sort"
+	"sync"
+
+	"github.com/urandom/graph"
+)
 
 // Linker provides a base implementation of graph.Linker
 type Linker struct {
@@ -35,35 +40,11 @@ func NewLinkerNode(node graph.Node) *Linker {
 }
 
 func (l *Linker) Connect(target graph.Linker, source, sink graph.Connector) error {
-	if source == nil || sink == nil {
-		return graph.ErrInvalidConnector
-	}
-
-	source = l.Connector(source.Name(), source.Type())
-	sink = target.Connector(sink.Name(), sink.Type())
-
-	if source == nil || sink == nil {
-		return graph.ErrInvalidConnector
-	}
-
-	if err := source.Connect(target, sink); err != nil {
-		return err
-	}
-
-	if err := sink.Connect(l, source); err != nil {
-		source.Disconnect()
-		return err
-	}
-
-	return nil
+	return connect(l, target, source, sink)
 }
 
 func (l *Linker) Disconnect(source graph.Connector) {
-	if _, tc := source.Target(); tc != nil {
-		tc.Disconnect()
-	}
-
-	source.Disconnect()
+	disconnect(source)
 }
 
 func (l *Linker) Link(target graph.Linker) {
@@ -78,6 +59,29 @@ func (l *Linker) Unlink() {
 	}
 }
 
+// AddConnector adds a connector to the linker. A connector of the same name
+// and type is disconnected and replaced
+func (l *Linker) AddConnector(c graph.Connector) {
+	connectors := l.connectors(c.Type())
+	if old, ok := connectors[c.Name()]; ok && old != c {
+		disconnect(old)
+	}
+
+	connectors[c.Name()] = c
+}
+
+// RemoveConnector disconnects and removes the connector of the given name and
+// type. If no type is provided, the input connector is removed
+func (l *Linker) RemoveConnector(name graph.ConnectorName, kind ...graph.ConnectorType) {
+	c := l.Connector(name, kind...)
+	if c == nil {
+		return
+	}
+
+	disconnect(c)
+	delete(l.connectors(c.Type()), name)
+}
+
 func (l Linker) Connector(name graph.ConnectorName, kind ...graph.ConnectorType) graph.Connector {
 	t := graph.InputType
 	if len(kind) > 0 {
@@ -94,6 +98,7 @@ func (l Linker) Connector(name graph.ConnectorName, kind ...graph.ConnectorType)
 	return nil
 }
 
+// Connectors returns all connectors of a given type, sorted by their names
 func (l Linker) Connectors(kind ...graph.ConnectorType) []graph.Connector {
 	t := graph.InputType
 	if len(kind) > 0 {
@@ -114,6 +119,10 @@ func (l Linker) Connectors(kind ...graph.ConnectorType) []graph.Connector {
 		connectors = append(connectors, v)
 	}
 
+	sort.Slice(connectors, func(i, j int) bool {
+		return connectors[i].Name() < connectors[j].Name()
+	})
+
 	return connectors
 }
 
@@ -144,3 +153,73 @@ func (l Linker) Connection(source ...graph.Connector) (graph.Linker, graph.Conne
 func (l Linker) Node() graph.Node {
 	return l.Data
 }
+
+func (l Linker) connectors(kind graph.ConnectorType) map[graph.ConnectorName]graph.Connector {
+	if kind == graph.OutputType {
+		return l.OutputConnectors
+	}
+
+	return l.InputConnectors
+}
+
+// connections serialises all changes to the connections between linkers.
+// Connecting two connectors may also displace the previous target of an
+// input connector, which involves the connectors of up to four linkers, and
+// each change has to be seen as a whole by the next one.
+var connections sync.Mutex
+
+// connect connects the source connector of a linker to the sink connector of
+// the target, looking both of them up by their names and types
+func connect(l, target graph.Linker, source, sink graph.Connector) error {
+	defer connections.Unlock()
+	connections.Lock()
+
+	if source == nil || sink == nil {
+		return graph.ErrInvalidConnector
+	}
+
+	source = l.Connector(source.Name(), source.Type())
+	sink = target.Connector(sink.Name(), sink.Type())
+
+	if source == nil || sink == nil {
+		return graph.ErrInvalidConnector
+	}
+
+	if source.Type() == sink.Type() {
+		return graph.ErrSameConnectorType
+	}
+
+	// An input connector can only have a single target, so its current
+	// target has to let go of it before it is connected elsewhere
+	for _, c := range []graph.Connector{source, sink} {
+		if c.Type() != graph.InputType {
+			continue
+		}
+
+		if _, tc := c.Target(); tc != nil && tc != source && tc != sink {
+			tc.Disconnect(c)
+		}
+	}
+
+	if err := source.Connect(target, sink); err != nil {
+		return err
+	}
+
+	if err := sink.Connect(l, source); err != nil {
+		source.Disconnect(sink)
+		return err
+	}
+
+	return nil
+}
+
+func disconnect(source graph.Connector) {
+	defer connections.Unlock()
+	connections.Lock()
+
+	for _, t := range source.Targets() {
+		t.Connector.Disconnect(source)
+	}
+
+	source.Disconnect()
+}
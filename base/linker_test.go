@@ -52,3 +52,97 @@ func TestLinker(t *testing.T) {
 		t.Fatalf("Connection to %v via %v from %v shouldn't exist\n", n, o, l1)
 	}
 }
+
+func TestLinkerFanOut(t *testing.T) {
+	var l1 graph.Linker = NewLinker()
+	var l2 graph.Linker = NewLinker()
+	var l3 graph.Linker = NewLinker()
+
+	l1.Link(l2)
+	l1.Link(l3)
+
+	c := l1.Connector(graph.OutputName, graph.OutputType)
+	if targets := c.Targets(); len(targets) != 2 {
+		t.Fatalf("Expected %v targets, got %v\n", 2, len(targets))
+	}
+
+	for _, l := range []graph.Linker{l2, l3} {
+		if lt, _ := l.Connection(); lt != l1 {
+			t.Fatalf("Expected %v to be connected to %v\n", l, l1)
+		}
+	}
+
+	var l4 graph.Linker = NewLinker()
+	l4.Link(l3)
+
+	if targets := c.Targets(); len(targets) != 1 || targets[0].Linker != l2 {
+		t.Fatalf("Expected %v to only be connected to %v\n", l1, l2)
+	}
+
+	if lt, _ := l3.Connection(); lt != l4 {
+		t.Fatalf("Expected %v to be connected to %v\n", l3, l4)
+	}
+
+	l1.Link(l3)
+	l1.Unlink()
+
+	if targets := c.Targets(); len(targets) != 0 {
+		t.Fatalf("Expected no targets, got %v\n", targets)
+	}
+
+	for _, l := range []graph.Linker{l2, l3} {
+		if lt, _ := l.Connection(); lt != nil {
+			t.Fatalf("Expected %v to be disconnected\n", l)
+		}
+	}
+}
+
+func TestLinkerConnectorsOrder(t *testing.T) {
+	l := NewLinker()
+
+	for _, name := range []graph.ConnectorName{"d", "b", "c", "a"} {
+		c := NewInputConnector(name)
+		l.InputConnectors[c.Name()] = c
+	}
+
+	expected := []graph.ConnectorName{"Input", "a", "b", "c", "d"}
+	for i := 0; i < 10; i++ {
+		connectors := l.Connectors()
+		if len(connectors) != len(expected) {
+			t.Fatalf("Expected %v connectors, got %v\n", len(expected), len(connectors))
+		}
+
+		for j, c := range connectors {
+			if c.Name() != expected[j] {
+				t.Fatalf("Expected %v, got %v\n", expected[j], c.Name())
+			}
+		}
+	}
+}
+
+func TestLinkerAddRemoveConnector(t *testing.T) {
+	l1, l2 := NewLinker(), NewLinker()
+
+	aux := NewInputConnector("aux")
+	l2.AddConnector(aux)
+
+	if err := l1.Connect(l2, l1.Connector(graph.OutputName, graph.OutputType), l2.Connector("aux")); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	l2.AddConnector(NewInputConnector("aux"))
+	if targets := l1.Connector(graph.OutputName, graph.OutputType).Targets(); len(targets) != 0 {
+		t.Fatalf("Expected no targets, got %v\n", targets)
+	}
+
+	l1.Link(l2)
+	l2.RemoveConnector(graph.InputName)
+
+	if c := l2.Connector(graph.InputName); c != nil {
+		t.Fatalf("Expected no connector, got %v\n", c)
+	}
+
+	if targets := l1.Connector(graph.OutputName, graph.OutputType).Targets(); len(targets) != 0 {
+		t.Fatalf("Expected no targets, got %v\n", targets)
+	}
+}
@@ -7,12 +7,7 @@ type Node struct {
 	NodeId graph.Id
 }
 
-const maxUint = ^uint64(0)
-const minAutoId = uint64(^uint16(0))
-
-var counter uint64 = minAutoId
-
-// NewNode creates a new node with an incremental id
+// NewNode creates a new node with an id from the current IdGenerator
 func NewNode() Node {
 	return Node{NodeId: nextId()}
 }
@@ -20,15 +15,3 @@ func NewNode() Node {
 func (n Node) Id() graph.Id {
 	return n.NodeId
 }
-
-func nextId() graph.Id {
-	id := graph.Id(counter)
-
-	if counter == maxUint {
-		counter = minAutoId
-	} else {
-		counter++
-	}
-
-	return id
-}
@@ -0,0 +1,103 @@
+package base
+
+import (
+	"sync"
+
+	"github.com/urandom/graph"
+)
+
+// SyncLinker is an implementation of graph.Linker that is safe for concurrent
+// use. Its connectors may be added, removed, connected and disconnected while
+// other goroutines traverse the graph, such as when creating a walker.
+// Connections and disconnections are applied one at a time, so concurrent
+// connections to the same input connector leave it connected to exactly one
+// of the output connectors, which is connected back to it.
+type SyncLinker struct {
+	mu     sync.RWMutex
+	linker Linker
+}
+
+// NewSyncLinker creates a new synchronised linker with a node and adds the
+// default input and output connectors
+func NewSyncLinker() *SyncLinker {
+	return NewSyncLinkerNode(NewNode())
+}
+
+func NewSyncLinkerNode(node graph.Node) *SyncLinker {
+	return &SyncLinker{linker: *NewLinkerNode(node)}
+}
+
+func (s *SyncLinker) Connect(target graph.Linker, source, sink graph.Connector) error {
+	return connect(s, target, source, sink)
+}
+
+func (s *SyncLinker) Disconnect(source graph.Connector) {
+	disconnect(source)
+}
+
+func (s *SyncLinker) Link(target graph.Linker) {
+	s.Connect(target, s.Connector(graph.OutputName, graph.OutputType), target.Connector(graph.InputName))
+}
+
+func (s *SyncLinker) Unlink() {
+	c := s.Connector(graph.OutputName, graph.OutputType)
+
+	if t, _ := c.Target(); t != nil {
+		s.Disconnect(c)
+	}
+}
+
+// AddConnector adds a connector to the linker. A connector of the same name
+// and type is disconnected and replaced
+func (s *SyncLinker) AddConnector(c graph.Connector) {
+	s.mu.Lock()
+	connectors := s.linker.connectors(c.Type())
+	old, ok := connectors[c.Name()]
+	connectors[c.Name()] = c
+	s.mu.Unlock()
+
+	if ok && old != c {
+		disconnect(old)
+	}
+}
+
+// RemoveConnector disconnects and removes the connector of the given name and
+// type. If no type is provided, the input connector is removed
+func (s *SyncLinker) RemoveConnector(name graph.ConnectorName, kind ...graph.ConnectorType) {
+	s.mu.Lock()
+	c := s.linker.Connector(name, kind...)
+	if c != nil {
+		delete(s.linker.connectors(c.Type()), name)
+	}
+	s.mu.Unlock()
+
+	if c != nil {
+		disconnect(c)
+	}
+}
+
+func (s *SyncLinker) Connector(name graph.ConnectorName, kind ...graph.ConnectorType) graph.Connector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.linker.Connector(name, kind...)
+}
+
+// Connectors returns all connectors of a given type, sorted by their names
+func (s *SyncLinker) Connectors(kind ...graph.ConnectorType) []graph.Connector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.linker.Connectors(kind...)
+}
+
+func (s *SyncLinker) Connection(source ...graph.Connector) (graph.Linker, graph.Connector) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.linker.Connection(source...)
+}
+
+func (s *SyncLinker) Node() graph.Node {
+	return s.linker.Data
+}
@@ -0,0 +1,117 @@
+package base
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urandom/graph"
+)
+
+func TestSyncLinker(t *testing.T) {
+	var l1, l2 graph.Linker = NewSyncLinker(), NewSyncLinker()
+
+	l1.Link(l2)
+
+	if target, _ := l1.Connection(l1.Connector(graph.OutputName, graph.OutputType)); target != l2 {
+		t.Fatalf("Expected %v, got %v\n", l2, target)
+	}
+
+	if target, _ := l2.Connection(); target != l1 {
+		t.Fatalf("Expected %v, got %v\n", l1, target)
+	}
+
+	if err := graph.Validate(l1); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	l1.Unlink()
+	if target, _ := l2.Connection(); target != nil {
+		t.Fatalf("Expected no target, got %v\n", target)
+	}
+}
+
+// TestSyncLinkerConcurrent is meant to be run with the race detector
+func TestSyncLinkerConcurrent(t *testing.T) {
+	root := NewSyncLinker()
+
+	var children []*SyncLinker
+	for i := 0; i < 4; i++ {
+		l := NewSyncLinker()
+		root.Link(l)
+
+		children = append(children, l)
+	}
+
+	var wg sync.WaitGroup
+	for i, l := range children {
+		wg.Add(2)
+
+		go func(i int, l *SyncLinker) {
+			defer wg.Done()
+
+			name := graph.ConnectorName(fmt.Sprintf("aux%d", i))
+			for j := 0; j < 100; j++ {
+				l.AddConnector(NewInputConnector(name))
+				root.Connect(l, root.Connector(graph.OutputName, graph.OutputType), l.Connector(name))
+				root.Disconnect(root.Connector(graph.OutputName, graph.OutputType))
+				root.Link(l)
+				l.RemoveConnector(name)
+			}
+		}(i, l)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				graph.NewWalker(root)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// slowConnector widens the window between reading the current target of an
+// input connector and replacing it
+type slowConnector struct {
+	*Connector
+}
+
+func (c slowConnector) Target() (graph.Linker, graph.Connector) {
+	l, t := c.Connector.Target()
+	time.Sleep(20 * time.Millisecond)
+
+	return l, t
+}
+
+func TestSyncLinkerConcurrentConnect(t *testing.T) {
+	a, b, c := NewSyncLinker(), NewSyncLinker(), NewSyncLinker()
+	c.AddConnector(slowConnector{NewInputConnector()})
+
+	var wg sync.WaitGroup
+	for _, l := range []*SyncLinker{a, b} {
+		wg.Add(1)
+		go func(l *SyncLinker) {
+			defer wg.Done()
+			l.Link(c)
+		}(l)
+	}
+	wg.Wait()
+
+	connected := 0
+	for _, l := range []*SyncLinker{a, b} {
+		if err := graph.Validate(l); err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if target, _ := l.Connection(l.Connector(graph.OutputName, graph.OutputType)); target != nil {
+			connected++
+		}
+	}
+
+	if connected != 1 {
+		t.Fatalf("Expected %v, got %v\n", 1, connected)
+	}
+}
@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Graph is a container that tracks its linkers by the ids of their nodes.
+// Unlike a walker created from a single linker, which has to discover the
+// rest of the graph through its connections, a Graph knows about all of its
+// linkers, including the ones that form separate, disconnected components.
+// Only connections between the linkers of a Graph are taken into account. A
+// Graph is safe for concurrent use, though changing the connections of its
+// linkers also requires them to be safe for concurrent use.
+type Graph struct {
+	mu      sync.RWMutex
+	linkers map[Id]Linker
+}
+
+// Edge is a connection from an output connector to an input connector
+type Edge struct {
+	From Endpoint
+	To   Endpoint
+}
+
+// NewGraph creates a new graph containing the given linkers
+func NewGraph(linkers ...Linker) (*Graph, error) {
+	g := &Graph{linkers: make(map[Id]Linker)}
+
+	if err := g.Add(linkers...); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Add adds the linkers to the graph. Linkers that are already a part of it are
+// ignored. An error wrapping ErrDuplicateId is returned, and no linker is
+// added, if a different linker with the same id is already a part of the
+// graph, or is given more than once.
+func (g *Graph) Add(linkers ...Linker) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	added := make(map[Id]Linker, len(linkers))
+	for _, l := range linkers {
+		id := l.Node().Id()
+
+		known, ok := g.linkers[id]
+		if !ok {
+			known, ok = added[id]
+		}
+
+		if ok && !sameLinker(known, l) {
+			return fmt.Errorf("node %d: %w", id, ErrDuplicateId)
+		}
+
+		added[id] = l
+	}
+
+	for id, l := range added {
+		if _, ok := g.linkers[id]; !ok {
+			g.linkers[id] = l
+		}
+	}
+
+	return nil
+}
+
+// Remove disconnects the linker with the given id from all of its targets, and
+// removes it from the graph. It returns false if the graph does not contain
+// such a linker
+func (g *Graph) Remove(id Id) bool {
+	g.mu.Lock()
+	l, ok := g.linkers[id]
+	delete(g.linkers, id)
+	g.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	for _, kind := range []ConnectorType{InputType, OutputType} {
+		for _, c := range l.Connectors(kind) {
+			l.Disconnect(c)
+		}
+	}
+
+	return true
+}
+
+// Get returns the linker with the given id
+func (g *Graph) Get(id Id) (Linker, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	l, ok := g.linkers[id]
+	return l, ok
+}
+
+// Len returns the number of linkers in the graph
+func (g *Graph) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return len(g.linkers)
+}
+
+// Linkers returns all linkers of the graph, sorted by their ids
+func (g *Graph) Linkers() []Linker {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	linkers := make([]Linker, 0, len(g.linkers))
+	for _, l := range g.linkers {
+		linkers = append(linkers, l)
+	}
+	sortById(linkers)
+
+	return linkers
+}
+
+// Roots returns the linkers that have no parents in the graph, sorted by their
+// ids
+func (g *Graph) Roots() []Linker {
+	roots, _, _, _ := findRoots(g.Linkers())
+	sortById(roots)
+
+	return roots
+}
+
+// Leaves returns the linkers that have no children in the graph, sorted by
+// their ids
+func (g *Graph) Leaves() []Linker {
+	linkers := g.Linkers()
+	_, _, children, _ := findRoots(linkers)
+
+	var leaves []Linker
+	for _, l := range linkers {
+		if len(children[l.Node().Id()]) == 0 {
+			leaves = append(leaves, l)
+		}
+	}
+
+	return leaves
+}
+
+// Edges returns the connections between the linkers of the graph, ordered by
+// the ids of their source linkers, the names of their output connectors, and
+// the ids of their target linkers
+func (g *Graph) Edges() []Edge {
+	linkers := g.Linkers()
+
+	members := NewVisitor()
+	for _, l := range linkers {
+		members.Add(l.Node())
+	}
+
+	var edges []Edge
+	for _, l := range linkers {
+		for _, c := range sortedOutputs(l) {
+			var targets []Endpoint
+			for _, t := range c.Targets() {
+				if t.Linker != nil && members.Visited(t.Linker.Node()) {
+					targets = append(targets, t)
+				}
+			}
+
+			sort.SliceStable(targets, func(i, j int) bool {
+				return targets[i].Linker.Node().Id() < targets[j].Linker.Node().Id()
+			})
+
+			for _, t := range targets {
+				edges = append(edges, Edge{From: Endpoint{Linker: l, Connector: c}, To: t})
+			}
+		}
+	}
+
+	return edges
+}
+
+// Validate checks the graph in the same way as the package-level Validate,
+// taking all of its linkers into account
+func (g *Graph) Validate() error {
+	return validate(g.Linkers())
+}
+
+// NewWalker creates a walker over all linkers of the graph, walking the roots
+// of all of its components simultaneously. An error is returned if the graph
+// is not valid, as described by Validate
+func (g *Graph) NewWalker() (Walker, error) {
+	return newWalker(g.Linkers())
+}
+
+// sameLinker reports whether both linkers are the same one, by checking that
+// they share all of their connectors. Linkers that embed another one may be
+// different values of the same linker
+func sameLinker(a, b Linker) bool {
+	for _, kind := range []ConnectorType{InputType, OutputType} {
+		connectors := b.Connectors(kind)
+		if len(connectors) != len(a.Connectors(kind)) {
+			return false
+		}
+
+		for _, c := range connectors {
+			if a.Connector(c.Name(), c.Type()) != c {
+				return false
+			}
+		}
+	}
+
+	return true
+}
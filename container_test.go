@@ -0,0 +1,136 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestGraph(t *testing.T) {
+	linkers := setupGraph()
+
+	// A separate component, which cannot be reached from the first one
+	l1, l2 := base.NewLinker(), base.NewLinker()
+	l1.Link(l2)
+
+	g, err := graph.NewGraph(append(linkers, l1, l2)...)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := len(linkers) + 2
+	if g.Len() != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, g.Len())
+	}
+
+	if l, ok := g.Get(l2.Node().Id()); !ok || l != l2 {
+		t.Fatalf("Expected %v, got %v\n", l2, l)
+	}
+
+	expectedIds := []graph.Id{linkers[0].Node().Id(), linkers[5].Node().Id(),
+		linkers[6].Node().Id(), linkers[10].Node().Id(), l1.Node().Id()}
+	if ids := linkerIds(g.Roots()); !equalIds(ids, expectedIds) {
+		t.Fatalf("Expected %v, got %v\n", expectedIds, ids)
+	}
+
+	expectedIds = []graph.Id{linkers[8].Node().Id(), linkers[11].Node().Id(), l2.Node().Id()}
+	if ids := linkerIds(g.Leaves()); !equalIds(ids, expectedIds) {
+		t.Fatalf("Expected %v, got %v\n", expectedIds, ids)
+	}
+
+	expectedInt = 12
+	edges := g.Edges()
+	if len(edges) != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, len(edges))
+	}
+
+	last := edges[len(edges)-1]
+	if last.From.Linker != l1 || last.To.Linker != l2 || last.To.Connector.Name() != graph.InputName {
+		t.Fatalf("Unexpected edge %v\n", last)
+	}
+
+	w, err := g.NewWalker()
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt = len(linkers) + 2
+	if w.Total() != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, w.Total())
+	}
+
+	count := 0
+	for wd := range w.Walk() {
+		count++
+		wd.Close()
+	}
+
+	if count != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, count)
+	}
+}
+
+func TestGraphAddRemove(t *testing.T) {
+	l1, l2, l3 := base.NewLinker(), base.NewLinker(), base.NewLinker()
+	l1.Link(l2)
+	l2.Link(l3)
+
+	g, err := graph.NewGraph(l1, l2, l3)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := g.Add(l1); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := g.Add(base.NewLinkerNode(l1.Node())); !errors.Is(err, graph.ErrDuplicateId) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrDuplicateId, err)
+	}
+
+	if !g.Remove(l2.Node().Id()) {
+		t.Fatalf("Expected node %v to be removed\n", l2.Node().Id())
+	}
+
+	if g.Remove(l2.Node().Id()) {
+		t.Fatalf("Expected node %v to be already removed\n", l2.Node().Id())
+	}
+
+	if _, ok := g.Get(l2.Node().Id()); ok {
+		t.Fatalf("Expected node %v to be missing\n", l2.Node().Id())
+	}
+
+	if edges := g.Edges(); len(edges) != 0 {
+		t.Fatalf("Expected no edges, got %v\n", edges)
+	}
+
+	expectedIds := []graph.Id{l1.Node().Id(), l3.Node().Id()}
+	if ids := linkerIds(g.Roots()); !equalIds(ids, expectedIds) {
+		t.Fatalf("Expected %v, got %v\n", expectedIds, ids)
+	}
+}
+
+func linkerIds(linkers []graph.Linker) []graph.Id {
+	ids := make([]graph.Id, len(linkers))
+	for i, l := range linkers {
+		ids[i] = l.Node().Id()
+	}
+
+	return ids
+}
+
+func equalIds(a, b []graph.Id) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
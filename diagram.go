@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Labeler is a Node that has a human-readable label, which is used when the
+// graph is exported as a diagram. Nodes that do not implement it are labelled
+// by their id
+type Labeler interface {
+	Label() string
+}
+
+// NodeStatus is the outcome of walking a node
+type NodeStatus int
+
+const (
+	// StatusUnknown is the status of a node that hasn't been walked
+	StatusUnknown NodeStatus = iota
+	// StatusSucceeded is the status of a node that was closed
+	StatusSucceeded
+	// StatusFailed is the status of a node that was failed
+	StatusFailed
+	// StatusSkipped is the status of a node that was skipped, due to a
+	// failed ancestor
+	StatusSkipped
+)
+
+// DiagramOptions control the output of WriteMermaid and WritePlantUML
+type DiagramOptions struct {
+	// Direction is the direction of a Mermaid flowchart, such as "LR" or
+	// "TB". If empty, "TB" is used
+	Direction string
+	// Status holds the status of each node of a completed walk, as returned
+	// by WalkStatus. If set, nodes are coloured by their status
+	Status map[Id]NodeStatus
+}
+
+// diagram is the exportable representation of the graph that would be walked
+// from a starting linker
+type diagram struct {
+	nodes []diagramNode
+	edges []diagramEdge
+}
+
+type diagramNode struct {
+	node  Node
+	label string
+	root  bool
+}
+
+type diagramEdge struct {
+	from, to      Id
+	output, input ConnectorName
+}
+
+// newDiagram collects the nodes and edges of the graph in the same way as a
+// walker does. Nodes come after their parents, if the graph has no cycles
+func newDiagram(start Linker) diagram {
+	linkers := findLinkers(start)
+	sortById(linkers)
+
+	roots, _, _, _ := findRoots(linkers)
+
+	byId := make(map[Id]Linker, len(linkers))
+	for _, l := range linkers {
+		byId[l.Node().Id()] = l
+	}
+
+	var ordered []Linker
+	for _, n := range sortLinkers(linkers) {
+		ordered = append(ordered, byId[n.Id()])
+		delete(byId, n.Id())
+	}
+
+	// Nodes that are part of a cycle are never sorted
+	for _, l := range linkers {
+		if _, ok := byId[l.Node().Id()]; ok {
+			ordered = append(ordered, l)
+		}
+	}
+
+	isRoot := NewVisitor()
+	for _, r := range roots {
+		isRoot.Add(r.Node())
+	}
+
+	members := NewVisitor()
+	for _, l := range linkers {
+		members.Add(l.Node())
+	}
+
+	var d diagram
+	for _, l := range ordered {
+		d.nodes = append(d.nodes, diagramNode{node: l.Node(),
+			label: nodeLabel(l.Node()), root: isRoot.Visited(l.Node())})
+
+		for _, c := range sortedOutputs(l) {
+			for _, t := range c.Targets() {
+				if t.Linker == nil || t.Connector == nil || !members.Visited(t.Linker.Node()) {
+					continue
+				}
+
+				d.edges = append(d.edges, diagramEdge{from: l.Node().Id(),
+					to: t.Linker.Node().Id(), output: c.Name(), input: t.Connector.Name()})
+			}
+		}
+	}
+
+	return d
+}
+
+func nodeLabel(n Node) string {
+	if l, ok := n.(Labeler); ok {
+		return l.Label()
+	}
+
+	return fmt.Sprint(n.Id())
+}
+
+// WalkStatus returns the status of each node of the walker's graph, given the
+// error that a completed walk has returned, such as the one returned by an
+// Executor. If the walk has been stopped, the status of its nodes is not
+// known, and nil is returned
+func WalkStatus(w Walker, err error) map[Id]NodeStatus {
+	var werr *WalkError
+	if err != nil && !errors.As(err, &werr) {
+		return nil
+	}
+
+	status := make(map[Id]NodeStatus, w.count)
+	for _, r := range w.roots {
+		status[r.Node().Id()] = StatusSucceeded
+	}
+
+	for _, children := range w.children {
+		for _, c := range children {
+			status[c.Node().Id()] = StatusSucceeded
+		}
+	}
+
+	if werr != nil {
+		for id, err := range werr.Errors {
+			if errors.Is(err, ErrSkipped) {
+				status[id] = StatusSkipped
+			} else {
+				status[id] = StatusFailed
+			}
+		}
+	}
+
+	return status
+}
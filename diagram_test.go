@@ -0,0 +1,106 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestWalkStatus(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	walker, errc := w.WalkContext(context.Background())
+	for wd := range walker {
+		if wd.Node.Id() == linkers[2].Node().Id() {
+			wd.Fail(errors.New("failure"))
+		} else {
+			wd.Close()
+		}
+	}
+
+	status := graph.WalkStatus(w, <-errc)
+
+	expected := map[int]graph.NodeStatus{
+		0: graph.StatusSucceeded, 2: graph.StatusFailed, 9: graph.StatusSkipped, 10: graph.StatusSucceeded,
+	}
+	for i, s := range expected {
+		if status[linkers[i].Node().Id()] != s {
+			t.Fatalf("Expected %v, got %v\n", s, status[linkers[i].Node().Id()])
+		}
+	}
+
+	if status := graph.WalkStatus(w, context.Canceled); status != nil {
+		t.Fatalf("Expected no status, got %v\n", status)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	load := base.NewLinkerNode(labeledNode{Node: base.NewNode(), label: `Load "in.png"`})
+	save := base.NewLinker()
+	load.Link(save)
+
+	lid, sid := load.Node().Id(), save.Node().Id()
+	status := map[graph.Id]graph.NodeStatus{lid: graph.StatusSucceeded, sid: graph.StatusFailed}
+
+	var b bytes.Buffer
+	if err := graph.WriteMermaid(&b, load, graph.DiagramOptions{Direction: "LR", Status: status}); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for _, expected := range []string{
+		"flowchart LR\n",
+		fmt.Sprintf(`n%d["Load #quot;in.png#quot;"]`, lid),
+		fmt.Sprintf(`n%d -->|"Output -> Input"| n%d`, lid, sid),
+		fmt.Sprintf("class n%d root\n", lid),
+		fmt.Sprintf("class n%d succeeded\n", lid),
+		fmt.Sprintf("class n%d failed\n", sid),
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Fatalf("Expected %s in %s\n", expected, b.String())
+		}
+	}
+}
+
+func TestWritePlantUML(t *testing.T) {
+	load := base.NewLinkerNode(labeledNode{Node: base.NewNode(), label: "Load"})
+	other := base.NewLinker()
+	save := base.NewLinker()
+	load.Link(save)
+
+	aux := base.NewInputConnector("aux")
+	save.InputConnectors[aux.Name()] = aux
+	other.Connect(save, other.Connector(graph.OutputName, graph.OutputType), aux)
+
+	lid, oid, sid := load.Node().Id(), other.Node().Id(), save.Node().Id()
+	status := map[graph.Id]graph.NodeStatus{sid: graph.StatusSkipped}
+
+	var b bytes.Buffer
+	if err := graph.WritePlantUML(&b, load, graph.DiagramOptions{Status: status}); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for _, expected := range []string{
+		"@startuml\n",
+		"BackgroundColor<<skipped>> #EEEEEE\n",
+		fmt.Sprintf("(*) --> \"Load\" as n%d\n", lid),
+		fmt.Sprintf("(*) --> \"%d\" as n%d\n", oid, oid),
+		fmt.Sprintf("n%d --> [Output -> Input] \"%d\" as n%d <<skipped>>\n", lid, sid, sid),
+		fmt.Sprintf("n%d --> [Output -> aux] n%d\n", oid, sid),
+		"@enduml\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Fatalf("Expected %s in %s\n", expected, b.String())
+		}
+	}
+}
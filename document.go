@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A DocumentDecoder decodes a graph document of some format, such as YAML or
+// TOML, into the json representation read by ProcessJSON
+type DocumentDecoder interface {
+	// Decode returns the next root of the document, or io.EOF if there are
+	// no more roots. If the returned error is a *DocumentError, its position
+	// is kept, and its path, if any, is taken as relative to the root.
+	Decode() (DocumentRoot, error)
+}
+
+// DocumentRoot is a single root of a graph document
+type DocumentRoot struct {
+	// JSON is the root, in the json representation read by ProcessJSON
+	JSON json.RawMessage
+	// Position returns the line and column of the value with the given json
+	// pointer path, relative to the root. Object keys have to be matched
+	// case-insensitively. If the value cannot be found, the position of its
+	// closest ancestor should be returned instead. It may be nil if positions
+	// are not known
+	Position func(path string) (line, column int)
+}
+
+// ProcessDocument converts the roots returned by the decoder into a graph
+// using the DefaultRegistry, and returns the root linkers, or an error. Each
+// root is processed as described by ProcessJSON, and any errors are reported
+// as DocumentErrors.
+func ProcessDocument(dec DocumentDecoder) ([]Linker, error) {
+	return DefaultRegistry.ProcessDocument(dec)
+}
+
+// ProcessDocument converts the roots returned by the decoder into a graph,
+// using the constructors of the registry. The roots are processed as
+// described by the package-level ProcessDocument function
+func (reg *Registry) ProcessDocument(dec DocumentDecoder) ([]Linker, error) {
+	p := newJSONProcessor(reg)
+	roots, _, err := p.result(p.processFile(&jsonFile{dec: dec}))
+
+	return roots, err
+}
+
+// jsonDocument decodes the roots of a json document, keeping track of their
+// offsets within the input
+type jsonDocument struct {
+	dec     *json.Decoder
+	offsets []int64
+}
+
+func (d *jsonDocument) Decode() (DocumentRoot, error) {
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		if serr, ok := err.(*json.SyntaxError); ok {
+			return DocumentRoot{}, &DocumentError{Offset: serr.Offset, Err: fmt.Errorf("decoding root: %w", err)}
+		} else if err == io.EOF {
+			return DocumentRoot{}, err
+		}
+
+		return DocumentRoot{}, fmt.Errorf("decoding root: %v", err)
+	}
+
+	d.offsets = append(d.offsets, d.dec.InputOffset()-int64(len(raw)))
+
+	return DocumentRoot{JSON: raw}, nil
+}
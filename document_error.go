@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DocumentError describes a problem with a linker in a graph document
+type DocumentError struct {
+	// Path is the json pointer of the offending value. Its first token is the
+	// index of the root within the list of roots of the document. It is empty
+	// for errors that precede the decoding of the document, such as missing
+	// template parameters
+	Path string
+	// Offset is the byte offset of the offending value within the input. It
+	// is only set for json documents
+	Offset int64
+	// Line and Column are the position of the offending value within the
+	// input, starting from 1. They are 0 if the position is not known, such
+	// as when the json input is given as a json decoder
+	Line, Column int
+	// File is the name of the file that contains the offending value, if the
+	// document was read from a file system
+	File string
+	// Name is the registered name of the linker, if known
+	Name string
+	// Err is the cause of the error
+	Err error
+}
+
+// DocumentErrors holds all errors that were found while processing a
+// document, in the order in which they were found
+type DocumentErrors []*DocumentError
+
+func (e *DocumentError) Error() string {
+	var b strings.Builder
+
+	if e.File != "" {
+		fmt.Fprintf(&b, "%s: ", e.File)
+	}
+
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", e.Line, e.Column)
+	}
+
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		if e.Name != "" {
+			fmt.Fprintf(&b, " (%s)", e.Name)
+		}
+		b.WriteString(": ")
+	}
+
+	fmt.Fprintf(&b, "%v", e.Err)
+
+	return b.String()
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+func (e DocumentErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e DocumentErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// setPosition sets the line and column of the error from its offset within
+// the text
+func (e *DocumentError) setPosition(text []byte) {
+	if e.Offset > int64(len(text)) {
+		return
+	}
+
+	e.Line = 1 + bytes.Count(text[:e.Offset], []byte("\n"))
+	e.Column = int(e.Offset) - bytes.LastIndexByte(text[:e.Offset], '\n')
+}
+
+// locate returns the offset of the value with the given json pointer tokens
+// within the raw json. Object keys are matched case-insensitively, like
+// encoding/json does, and the offset of the key is returned for object
+// members. If a token cannot be found, the offset of the closest found value
+// is returned
+func locate(raw []byte, tokens []string) int64 {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	offset := skipSeparators(raw, 0)
+
+	for _, token := range tokens {
+		t, err := dec.Token()
+		if err != nil {
+			return offset
+		}
+
+		found := false
+		switch t {
+		case json.Delim('{'):
+			for dec.More() {
+				start := skipSeparators(raw, dec.InputOffset())
+
+				k, err := dec.Token()
+				if err != nil {
+					return offset
+				}
+
+				if key, _ := k.(string); strings.EqualFold(key, token) {
+					offset, found = start, true
+					break
+				}
+
+				if skipValue(dec) != nil {
+					return offset
+				}
+			}
+		case json.Delim('['):
+			index, _ := strconv.Atoi(token)
+			for i := 0; dec.More(); i++ {
+				if i == index {
+					offset, found = skipSeparators(raw, dec.InputOffset()), true
+					break
+				}
+
+				if skipValue(dec) != nil {
+					return offset
+				}
+			}
+		}
+
+		if !found {
+			return offset
+		}
+	}
+
+	return offset
+}
+
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func skipSeparators(raw []byte, offset int64) int64 {
+	for offset < int64(len(raw)) && strings.IndexByte(" \t\r\n,:", raw[offset]) != -1 {
+		offset++
+	}
+
+	return offset
+}
+
+// splitPointer returns the unescaped reference tokens of a json pointer
+func splitPointer(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, t := range tokens {
+		t = strings.Replace(t, "~1", "/", -1)
+		tokens[i] = strings.Replace(t, "~0", "~", -1)
+	}
+
+	return tokens
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOTOptions control the output of WriteDOT
+type DOTOptions struct {
+	// Name is the name of the digraph. If empty, "graph" is used
+	Name string
+	// RankDir is the direction of the layout, such as "LR" or "TB". If
+	// empty, the default of graphviz is used
+	RankDir string
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteDOT writes the graph that would be walked from the given starting
+// linker in the graphviz DOT format. Nodes are labelled using the Labeler
+// interface, or by their ids, and root nodes are highlighted. Each edge is
+// labelled with the names of the output and input connectors it connects.
+func WriteDOT(w io.Writer, start Linker, opts DOTOptions) error {
+	d := newDiagram(start)
+
+	name := opts.Name
+	if name == "" {
+		name = "graph"
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+	if opts.RankDir != "" {
+		fmt.Fprintf(&b, "\trankdir=%s;\n", dotQuote(opts.RankDir))
+	}
+	b.WriteString("\tnode [shape=box];\n")
+
+	for _, n := range d.nodes {
+		fmt.Fprintf(&b, "\tn%d [label=%s", n.node.Id(), dotQuote(n.label))
+		if n.root {
+			b.WriteString(`, style=filled, fillcolor="lightblue"`)
+		}
+		b.WriteString("];\n")
+	}
+
+	for _, e := range d.edges {
+		fmt.Fprintf(&b, "\tn%d -> n%d [taillabel=%s, headlabel=%s];\n",
+			e.from, e.to, dotQuote(string(e.output)), dotQuote(string(e.input)))
+	}
+
+	b.WriteString("}\n")
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("writing dot graph: %v", err)
+	}
+
+	return nil
+}
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
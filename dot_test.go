@@ -0,0 +1,48 @@
+package graph_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+type labeledNode struct {
+	graph.Node
+
+	label string
+}
+
+func (n labeledNode) Label() string {
+	return n.label
+}
+
+func TestWriteDOT(t *testing.T) {
+	load := base.NewLinkerNode(labeledNode{Node: base.NewNode(), label: `Load "in.png"`})
+	save := base.NewLinker()
+
+	aux := base.NewInputConnector("aux")
+	save.InputConnectors[aux.Name()] = aux
+	load.Connect(save, load.Connector(graph.OutputName, graph.OutputType), aux)
+
+	var b bytes.Buffer
+	if err := graph.WriteDOT(&b, load, graph.DOTOptions{Name: "test", RankDir: "LR"}); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	lid, sid := load.Node().Id(), save.Node().Id()
+	for _, expected := range []string{
+		`digraph "test" {`,
+		`rankdir="LR";`,
+		fmt.Sprintf(`n%d [label="Load \"in.png\"", style=filled, fillcolor="lightblue"];`, lid),
+		fmt.Sprintf(`n%d [label="%d"];`, sid, sid),
+		fmt.Sprintf(`n%d -> n%d [taillabel="Output", headlabel="aux"];`, lid, sid),
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Fatalf("Expected %s in %s\n", expected, b.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package graph_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -8,19 +9,12 @@ import (
 	"github.com/urandom/graph/base"
 )
 
-type Processor interface {
-	Process(wd graph.WalkData, output chan<- int)
-	Result() int
-}
-
 type RandomNumberNode struct {
 	graph.Node
-	result int
 }
 
 type MultiplyNode struct {
 	graph.Node
-	result int
 }
 
 type SummingNode struct {
@@ -28,39 +22,36 @@ type SummingNode struct {
 	result int
 }
 
-func (n *RandomNumberNode) Process(wd graph.WalkData, output chan<- int) {
-	n.result = rand.Intn(50-10) + 10
+func (n RandomNumberNode) Process(ctx context.Context, wd graph.WalkData) error {
+	wd.Emit(graph.OutputName, rand.Intn(50-10)+10)
 
-	wd.Close()
+	return nil
 }
 
-func (n RandomNumberNode) Result() int {
-	return n.result
-}
-
-func (n *MultiplyNode) Process(wd graph.WalkData, output chan<- int) {
-	parent := wd.Parents[0]
-
-	if p, ok := parent.Node.(Processor); ok {
-		n.result = p.Result()*rand.Intn(10-1) + 1
+func (n MultiplyNode) Process(ctx context.Context, wd graph.WalkData) error {
+	v, err := graph.InputAs[int](wd, graph.InputName)
+	if err != nil {
+		return err
 	}
 
-	wd.Close()
-}
+	wd.Emit(graph.OutputName, v*rand.Intn(10-1)+1)
 
-func (n MultiplyNode) Result() int {
-	return n.result
+	return nil
 }
 
-func (n *SummingNode) Process(wd graph.WalkData, output chan<- int) {
-	for _, parent := range wd.Parents {
-		if p, ok := parent.Node.(Processor); ok {
-			n.result += p.Result()
+func (n *SummingNode) Process(ctx context.Context, wd graph.WalkData) error {
+	for _, name := range []graph.ConnectorName{graph.InputName, "aux"} {
+		v, err := graph.InputAs[int](wd, name)
+		if err != nil {
+			return err
 		}
+
+		n.result += v
 	}
 
-	wd.Close()
-	output <- n.result
+	wd.Emit(graph.OutputName, n.result)
+
+	return nil
 }
 
 func (n SummingNode) Result() int {
@@ -68,25 +59,22 @@ func (n SummingNode) Result() int {
 }
 
 func Example() {
-	root := CreateGraph()
+	root, sum := CreateGraph()
 
-	walker := graph.NewWalker(root)
-	data := walker.Walk()
+	walker, err := graph.NewWalker(root)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
-	output := make(chan int)
+	executor := graph.NewExecutor(4)
 
-	for wd := range data {
-		if p, ok := wd.Node.(Processor); ok {
-			go p.Process(wd, output)
-		} else {
-			wd.Close()
-		}
+	if err := executor.Run(context.Background(), walker); err != nil {
+		fmt.Println(err)
+		return
 	}
 
-	select {
-	case r := <-output:
-		fmt.Println(r)
-	}
+	fmt.Println(sum.Result())
 }
 
 /*
@@ -94,7 +82,7 @@ func Example() {
 	0 - 2 - 3
 	1 - - /
 */
-func CreateGraph() graph.Linker {
+func CreateGraph() (graph.Linker, *SummingNode) {
 	linkers := make([]graph.Linker, 4)
 
 	for i := range linkers {
@@ -102,11 +90,11 @@ func CreateGraph() graph.Linker {
 
 		switch i {
 		case 0:
-			l.Data = &RandomNumberNode{Node: l.Data}
+			l.Data = RandomNumberNode{Node: l.Data}
 		case 1:
-			l.Data = &RandomNumberNode{Node: l.Data}
+			l.Data = RandomNumberNode{Node: l.Data}
 		case 2:
-			l.Data = &MultiplyNode{Node: l.Data}
+			l.Data = MultiplyNode{Node: l.Data}
 			l.Connect(linkers[0], l.Connector(graph.InputName), linkers[0].Connector(graph.OutputName, graph.OutputType))
 		case 3:
 			c := base.NewInputConnector("aux")
@@ -120,5 +108,5 @@ func CreateGraph() graph.Linker {
 		linkers[i] = l
 	}
 
-	return linkers[0]
+	return linkers[0], linkers[3].Node().(*SummingNode)
 }
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Processor is a node that can be processed by an Executor
+type Processor interface {
+	// Process performs the node's operation. It is called only once all of
+	// the node's parents have been processed successfully. The walk data must
+	// not be closed or failed by the processor, the executor does that based
+	// on the returned error.
+	Process(ctx context.Context, wd WalkData) error
+}
+
+// Executor walks a graph and processes its nodes concurrently, using a
+// bounded pool of workers
+type Executor struct {
+	workers int
+}
+
+// NewExecutor creates an executor that processes at most the given number of
+// nodes at the same time. If the number is not positive, the number of CPUs
+// is used instead
+func NewExecutor(workers int) Executor {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return Executor{workers: workers}
+}
+
+// Run walks the graph of the given walker, and processes every node that
+// implements Processor. Nodes that do not implement it are considered done as
+// soon as they are walked. If a processor returns an error, the node is
+// failed and its descendants are skipped. Run returns once all nodes have
+// finished, or the context is done, with the error of the walk.
+func (e Executor) Run(ctx context.Context, w Walker) error {
+	nodes, errc := w.WalkContext(ctx)
+
+	workers := make(chan struct{}, e.workers)
+	var wg sync.WaitGroup
+
+	for wd := range nodes {
+		p, ok := wd.Node.(Processor)
+		if !ok {
+			wd.Close()
+			continue
+		}
+
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			wd.Fail(ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(wd WalkData) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			if err := p.Process(ctx, wd); err != nil {
+				wd.Fail(err)
+			} else {
+				wd.Close()
+			}
+		}(wd)
+	}
+
+	wg.Wait()
+
+	return <-errc
+}
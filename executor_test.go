@@ -0,0 +1,154 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+type processorNode struct {
+	graph.Node
+
+	mu      *sync.Mutex
+	active  *int
+	max     *int
+	visitor *graph.Visitor
+	err     error
+}
+
+func (n processorNode) Process(ctx context.Context, wd graph.WalkData) error {
+	n.mu.Lock()
+	*n.active++
+	if *n.active > *n.max {
+		*n.max = *n.active
+	}
+	n.mu.Unlock()
+
+	for _, p := range wd.Parents {
+		if !n.visitor.Visited(p.Node) {
+			return errors.New("parent hasn't been processed")
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	n.mu.Lock()
+	*n.active--
+	n.mu.Unlock()
+
+	if n.err != nil {
+		return n.err
+	}
+
+	n.visitor.Add(n.Node)
+
+	return nil
+}
+
+func TestExecutor(t *testing.T) {
+	linkers := setupGraph()
+
+	var mu sync.Mutex
+	var active, max int
+	v := graph.NewVisitor()
+
+	for _, l := range linkers {
+		bl := l.(*base.Linker)
+		bl.Data = processorNode{Node: bl.Data, mu: &mu, active: &active, max: &max, visitor: v}
+	}
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := graph.NewExecutor(2).Run(context.Background(), w); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for i, l := range linkers {
+		if !v.Visited(l.Node()) {
+			t.Fatalf("Node %d should have been processed\n", i)
+		}
+	}
+
+	if max > 2 {
+		t.Fatalf("Expected at most %v concurrent nodes, got %v\n", 2, max)
+	}
+}
+
+func TestExecutorFail(t *testing.T) {
+	linkers := setupGraph()
+
+	var mu sync.Mutex
+	var active, max int
+	v := graph.NewVisitor()
+	failure := errors.New("failure")
+
+	for i, l := range linkers {
+		bl := l.(*base.Linker)
+		n := processorNode{Node: bl.Data, mu: &mu, active: &active, max: &max, visitor: v}
+		if i == 9 {
+			n.err = failure
+		}
+		bl.Data = n
+	}
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	err = graph.NewExecutor(0).Run(context.Background(), w)
+
+	var werr *graph.WalkError
+	if !errors.As(err, &werr) {
+		t.Fatalf("Expected a walk error, got %v\n", err)
+	}
+
+	if err := werr.Errors[linkers[9].Node().Id()]; err != failure {
+		t.Fatalf("Expected %v, got %v\n", failure, err)
+	}
+
+	if err := werr.Errors[linkers[11].Node().Id()]; !errors.Is(err, graph.ErrSkipped) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrSkipped, err)
+	}
+
+	if v.Visited(linkers[11].Node()) {
+		t.Fatalf("Node 11 shouldn't have been processed\n")
+	}
+
+	if !v.Visited(linkers[8].Node()) {
+		t.Fatalf("Node 8 should have been processed\n")
+	}
+}
+
+func TestExecutorContext(t *testing.T) {
+	linkers := setupGraph()
+
+	var mu sync.Mutex
+	var active, max int
+	v := graph.NewVisitor()
+
+	for _, l := range linkers {
+		bl := l.(*base.Linker)
+		bl.Data = processorNode{Node: bl.Data, mu: &mu, active: &active, max: &max, visitor: v}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Millisecond)
+	defer cancel()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := graph.NewExecutor(1).Run(ctx, w); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got %v\n", context.DeadlineExceeded, err)
+	}
+}
@@ -25,6 +25,11 @@ const (
 var (
 	ErrSameConnectorType = errors.New("Two connectors of the same type cannot be linked together")
 	ErrInvalidConnector  = errors.New("The given connector is invalid")
+	ErrSkipped           = errors.New("The node was skipped due to a failed ancestor")
+	ErrMissingInput      = errors.New("No value was emitted for the input connector")
+	ErrDanglingConnector = errors.New("The connector's target is not part of a linker")
+	ErrHalfConnected     = errors.New("The connector's target is not connected back to it")
+	ErrDuplicateId       = errors.New("Different nodes of the graph share the same id")
 )
 
 // Node is a basic work unit within a graph
@@ -71,15 +76,28 @@ type Connector interface {
 	Type() ConnectorType
 	// Name returns the connector's name
 	Name() ConnectorName
-	// Target returns the linker and connector that are connected to this one
+	// Target returns the linker and connector that are connected to this one.
+	// If more than one are connected, the first one is returned
 	Target() (Linker, Connector)
+	// Targets returns all linkers and connectors that are connected to this
+	// one. Only output connectors may have more than one target
+	Targets() []Endpoint
 	// Connect connects the target linker and connector to this one. It only
 	// setups the link on its own end, the linker itself setups the reciprocal
-	// connection. It will return an error if the target connector is of the
-	// same type, or if the target connector is nil
+	// connection. An output connector adds the target to its existing ones,
+	// while an input connector replaces its current target. It will return an
+	// error if the target connector is of the same type, or if the target
+	// connector is nil
 	Connect(target Linker, connector Connector) error
-	// Disconnect breaks a connection with any linker that's currently
-	// connected. Similarly to the connect method, it only removes the link on
-	// its own end
-	Disconnect()
+	// Disconnect breaks the connection with the given target connectors, or
+	// with all linkers that are currently connected if none are given.
+	// Similarly to the connect method, it only removes the link on its own end
+	Disconnect(target ...Connector)
+}
+
+// Endpoint is a linker and one of its connectors, to which another connector
+// is connected
+type Endpoint struct {
+	Linker    Linker
+	Connector Connector
 }
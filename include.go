@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// jsonInclude is a root that is replaced by the roots of another document
+type jsonInclude struct {
+	// The name of the included file, relative to the including one
+	Include string `json:"include"`
+}
+
+// ProcessFS converts the json document with the given name, from the file
+// system, into a graph using the DefaultRegistry. It returns the root
+// linkers, or an error. The document is processed as described by
+// ProcessJSON, with each file being parsed using text/template if the
+// template data is not nil.
+//
+// In addition, a root of the document may be an object with a single
+// "Include" property, which names another json file, relative to the
+// directory of the including one. The include is replaced by the roots of
+// that file. Each file is included at most once, even if it is named by
+// several includes, and a file that includes itself, either directly or
+// through other files, is reported as an error wrapping ErrIncludeCycle.
+// Reference ids are shared by all files, so a "ReferenceTo" may refer to a
+// linker from any of them.
+//
+// {"Include": "common/load.json"}
+// {"ReferenceTo": "load", "Outputs": {"Output": {"Name": "Save"}}}
+//
+// Any DocumentErrors have their File set to the name of the file that
+// contains the offending value.
+func ProcessFS(fsys fs.FS, name string, templateData *JSONTemplateData) ([]Linker, error) {
+	return DefaultRegistry.ProcessFS(fsys, name, templateData)
+}
+
+// ProcessFS converts the json document with the given name, from the file
+// system, into a graph, using the constructors of the registry. The document
+// is processed as described by the package-level ProcessFS function
+func (reg *Registry) ProcessFS(fsys fs.FS, name string, templateData *JSONTemplateData) ([]Linker, error) {
+	if _, err := fs.Stat(fsys, name); err != nil {
+		return nil, fmt.Errorf("processing %s: %w", name, err)
+	}
+
+	p := newJSONProcessor(reg)
+	p.fsys, p.templateData = fsys, templateData
+
+	roots, _, err := p.result(p.include(name, ""))
+
+	return roots, err
+}
+
+// include processes the roots of the named file. The pointer is the json
+// pointer of the include within the current document
+func (p *jsonProcessor) include(name, pointer string) []Linker {
+	ipointer := jsonPointer(pointer, "include")
+
+	if p.fsys == nil {
+		p.fail(ipointer, "", fmt.Errorf("%s: %w", name, ErrIncludeWithoutFS))
+		return nil
+	}
+
+	if p.file != nil {
+		name = path.Join(path.Dir(p.file.name), name)
+	}
+
+	if p.including[name] {
+		p.fail(ipointer, "", fmt.Errorf("%s: %w", name, ErrIncludeCycle))
+		return nil
+	} else if p.included[name] {
+		return nil
+	}
+
+	data, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		p.fail(ipointer, "", fmt.Errorf("including %s: %w", name, err))
+		return nil
+	}
+
+	text, err := executeTemplate(data, p.templateData)
+	if err != nil {
+		if errs, ok := err.(DocumentErrors); ok {
+			for _, e := range errs {
+				e.File = name
+			}
+			p.errs = append(p.errs, errs...)
+		} else {
+			p.fail(ipointer, "", fmt.Errorf("including %s: %w", name, err))
+		}
+
+		return nil
+	}
+
+	p.included[name], p.including[name] = true, true
+	defer delete(p.including, name)
+
+	dec := json.NewDecoder(bytes.NewReader(text))
+
+	return p.processFile(&jsonFile{name: name, dec: &jsonDocument{dec: dec}, text: text})
+}
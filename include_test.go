@@ -0,0 +1,111 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/urandom/graph"
+)
+
+func TestProcessFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.json": {Data: []byte(`
+{"Include": "common/load.json"}
+{"Include": "common/load.json"}
+{
+	"Name": "Load",
+	"Options": {"Path": "other.png"},
+	"Outputs": {"ref": {"ReferenceTo": "save", "Input": "dup"}}
+}`)},
+		"common/load.json": {Data: []byte(`
+{"Include": "save.json"}
+{
+	"Name": "Load",
+	"Options": {"Path": {{ required "input" | json }}},
+	"Outputs": {"Output": {"ReferenceTo": "save"}}
+}`)},
+		"common/save.json": {Data: []byte(`{"Name": "Save", "Options": {"Path": "out.png"}, "ReferenceId": "save"}`)},
+	}
+
+	roots, err := graph.ProcessFS(fsys, "main.json", &graph.JSONTemplateData{Vars: map[string]interface{}{"input": "in.png"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := 3
+	if len(roots) != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, len(roots))
+	}
+
+	if n := roots[1].Node().(loadNode); n.opts.Path != "in.png" {
+		t.Fatalf("Expected %v, got %v\n", "in.png", n.opts.Path)
+	}
+
+	save := roots[1].Connector(graph.OutputName, graph.OutputType).Targets()[0].Linker
+	if save != roots[0] || save != roots[2].Connector("ref", graph.OutputType).Targets()[0].Linker {
+		t.Fatalf("Expected both roots to be connected to the same linker\n")
+	}
+
+	_, err = graph.ProcessFS(fsys, "main.json", &graph.JSONTemplateData{})
+
+	var derr *graph.DocumentError
+	if !errors.Is(err, graph.ErrMissingParameter) || !errors.As(err, &derr) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrMissingParameter, err)
+	}
+
+	if derr.File != "common/load.json" {
+		t.Fatalf("Expected %v, got %v\n", "common/load.json", derr.File)
+	}
+}
+
+func TestProcessFSForwardReference(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.json": {Data: []byte(`
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {"Output": {"ReferenceTo": "save"}}
+}
+{"Include": "save.json"}`)},
+		"save.json": {Data: []byte(`{"Name": "Save", "Options": {"Path": "out.png"}, "ReferenceId": "save"}`)},
+	}
+
+	roots, err := graph.ProcessFS(fsys, "main.json", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := 2
+	if len(roots) != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, len(roots))
+	}
+
+	if target, _ := roots[0].Connection(roots[0].Connector(graph.OutputName, graph.OutputType)); target != roots[1] {
+		t.Fatalf("Expected %v, got %v\n", roots[1], target)
+	}
+}
+
+func TestProcessFSCycle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.json": {Data: []byte(`{"Include": "b/b.json"}`)},
+		"b/b.json": {Data: []byte(`
+{"Name": "Pass"}
+{"Include": "../a.json"}`)},
+	}
+
+	_, err := graph.ProcessFS(fsys, "a.json", nil)
+
+	var derr *graph.DocumentError
+	if !errors.Is(err, graph.ErrIncludeCycle) || !errors.As(err, &derr) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrIncludeCycle, err)
+	}
+
+	if derr.File != "b/b.json" || derr.Path != "/1/include" || derr.Line != 3 {
+		t.Fatalf("Unexpected error %v\n", derr)
+	}
+
+	if _, err := graph.ProcessJSON(`{"Include": "a.json"}`, nil); !errors.Is(err, graph.ErrIncludeWithoutFS) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrIncludeWithoutFS, err)
+	}
+}
@@ -0,0 +1,149 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// A LinkerJSONMarshaler is a Linker, or the Node of a Linker, that can be
+// converted back into the json format read by ProcessJSON
+type LinkerJSONMarshaler interface {
+	// MarshalLinkerJSON returns the name under which the linker's constructor
+	// is registered, and the options that will be passed to it
+	MarshalLinkerJSON() (name string, options json.RawMessage, err error)
+}
+
+type jsonMarshaler struct {
+	reached    map[Id]int
+	references map[Id]jsonReference
+	visitor    *Visitor
+}
+
+// MarshalJSON converts the graphs that start from the given roots into the
+// format read by ProcessJSON. Each root is written as a separate json object.
+// Linkers that are reached more than once, such as the ones that join separate
+// branches, are given a "referenceId", which is used as a "referenceTo" by the
+// rest of their parents. A root that has already been written as the
+// descendant of a previous root is not written again.
+//
+// Every linker, or its node, has to implement LinkerJSONMarshaler.
+func MarshalJSON(roots []Linker) ([]byte, error) {
+	m := jsonMarshaler{reached: make(map[Id]int),
+		references: make(map[Id]jsonReference), visitor: NewVisitor()}
+
+	for _, r := range roots {
+		if m.reached[r.Node().Id()] == 0 {
+			m.reach(r)
+		}
+	}
+
+	var b bytes.Buffer
+	for _, r := range roots {
+		if m.visitor.Visited(r.Node()) {
+			continue
+		}
+
+		jl, err := m.linker(r)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.MarshalIndent(jl, "", "\t")
+		if err != nil {
+			return nil, fmt.Errorf("marshaling root %d: %v", r.Node().Id(), err)
+		}
+
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes(), nil
+}
+
+func (m jsonMarshaler) linker(l Linker) (jsonLinker, error) {
+	var jl jsonLinker
+
+	marshaler, ok := l.(LinkerJSONMarshaler)
+	if !ok {
+		if marshaler, ok = l.Node().(LinkerJSONMarshaler); !ok {
+			return jl, fmt.Errorf("linker of node %d is not a LinkerJSONMarshaler", l.Node().Id())
+		}
+	}
+
+	name, opts, err := marshaler.MarshalLinkerJSON()
+	if err != nil {
+		return jl, fmt.Errorf("marshaling linker of node %d: %v", l.Node().Id(), err)
+	}
+
+	m.visitor.Add(l.Node())
+	jl.Name = name
+	jl.Options = opts
+	jl.ReferenceId = m.reference(l)
+
+	for _, c := range sortedOutputs(l) {
+		for _, t := range c.Targets() {
+			var child jsonLinker
+
+			if m.visitor.Visited(t.Linker.Node()) {
+				child.ReferenceTo = m.reference(t.Linker)
+			} else if child, err = m.linker(t.Linker); err != nil {
+				return jl, err
+			}
+
+			if t.Connector.Name() != InputName {
+				child.Input = t.Connector.Name()
+			}
+
+			if jl.Outputs == nil {
+				jl.Outputs = make(map[ConnectorName]jsonOutputs)
+			}
+			jl.Outputs[c.Name()] = append(jl.Outputs[c.Name()], child)
+		}
+	}
+
+	return jl, nil
+}
+
+// reach counts the number of times each linker is reached when traversing the
+// graph in the same order as it is written
+func (m jsonMarshaler) reach(l Linker) {
+	m.reached[l.Node().Id()]++
+	if m.reached[l.Node().Id()] > 1 {
+		return
+	}
+
+	for _, c := range sortedOutputs(l) {
+		for _, t := range c.Targets() {
+			m.reach(t.Linker)
+		}
+	}
+}
+
+// reference returns the reference id of a linker that is reached more than
+// once, allocating a new one if needed. Other linkers have no reference id
+func (m jsonMarshaler) reference(l Linker) jsonReference {
+	if ref, ok := m.references[l.Node().Id()]; ok {
+		return ref
+	}
+
+	if m.reached[l.Node().Id()] < 2 {
+		return ""
+	}
+
+	ref := jsonReference(strconv.Itoa(len(m.references) + 1))
+	m.references[l.Node().Id()] = ref
+
+	return ref
+}
+
+func sortedOutputs(l Linker) []Connector {
+	outputs := l.Connectors(OutputType)
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Name() < outputs[j].Name()
+	})
+
+	return outputs
+}
@@ -0,0 +1,97 @@
+package graph_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	for _, data := range []string{testData1, testTwoRoots, testBranch} {
+		roots, err := graph.ProcessJSON(data, nil)
+		if err != nil {
+			t.Fatalf("processing data: %v", err)
+		}
+
+		b, err := graph.MarshalJSON(roots)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		again, err := graph.ProcessJSON(b, nil)
+		if err != nil {
+			t.Fatalf("processing marshaled data: %v\n%s", err, b)
+		}
+
+		if len(again) != len(roots) {
+			t.Fatalf("Expected %v roots, got %v\n", len(roots), len(again))
+		}
+
+		b2, err := graph.MarshalJSON(again)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if !bytes.Equal(b, b2) {
+			t.Fatalf("Expected %s, got %s\n", b, b2)
+		}
+	}
+}
+
+func TestMarshalJSONReferences(t *testing.T) {
+	roots, err := graph.ProcessJSON(testBranch, nil)
+	if err != nil {
+		t.Fatalf("processing testBranch: %v", err)
+	}
+
+	b, err := graph.MarshalJSON(roots)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for _, expected := range []string{`"referenceId": "1"`, `"referenceTo": "1"`, `"input": "dup"`} {
+		if !strings.Contains(string(b), expected) {
+			t.Fatalf("Expected %s in %s\n", expected, b)
+		}
+	}
+}
+
+func TestMarshalJSONFanOut(t *testing.T) {
+	roots, err := graph.ProcessJSON(testData1, nil)
+	if err != nil {
+		t.Fatalf("processing testData1: %v", err)
+	}
+
+	l := base.NewLinkerNode(saveNode{Node: base.NewNode(), opts: saveOptions{Path: "3"}})
+	roots[0].Link(l)
+
+	b, err := graph.MarshalJSON(roots)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	roots, err = graph.ProcessJSON(b, nil)
+	if err != nil {
+		t.Fatalf("processing marshaled data: %v\n%s", err, b)
+	}
+
+	targets := roots[0].Connector(graph.OutputName, graph.OutputType).Targets()
+	if len(targets) != 2 {
+		t.Fatalf("Expected %v targets, got %v\n", 2, len(targets))
+	}
+
+	for i, path := range []string{"2", "3"} {
+		if n := targets[i].Linker.Node().(saveNode); n.opts.Path != path {
+			t.Fatalf("Expected %s, got %s\n", path, n.opts.Path)
+		}
+	}
+}
+
+func TestMarshalJSONUnsupported(t *testing.T) {
+	if _, err := graph.MarshalJSON([]graph.Linker{base.NewLinker()}); err == nil {
+		t.Fatalf("Expected an error for a linker without a marshaler\n")
+	}
+}
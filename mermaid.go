@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "\n", "<br>")
+
+var statusClasses = map[NodeStatus]string{
+	StatusSucceeded: "succeeded",
+	StatusFailed:    "failed",
+	StatusSkipped:   "skipped",
+}
+
+// WriteMermaid writes the graph that would be walked from the given starting
+// linker as a Mermaid flowchart. Nodes are labelled using the Labeler
+// interface, or by their ids, and root nodes are drawn with a thicker border.
+// Each edge is labelled with the names of the output and input connectors it
+// connects. If the options hold the status of a walk, nodes are coloured by
+// their status.
+func WriteMermaid(w io.Writer, start Linker, opts DiagramOptions) error {
+	d := newDiagram(start)
+
+	direction := opts.Direction
+	if direction == "" {
+		direction = "TB"
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "flowchart %s\n", direction)
+
+	for _, n := range d.nodes {
+		fmt.Fprintf(&b, "\tn%d[\"%s\"]\n", n.node.Id(), mermaidEscaper.Replace(n.label))
+	}
+
+	for _, e := range d.edges {
+		fmt.Fprintf(&b, "\tn%d -->|\"%s -> %s\"| n%d\n", e.from,
+			mermaidEscaper.Replace(string(e.output)), mermaidEscaper.Replace(string(e.input)), e.to)
+	}
+
+	b.WriteString("\tclassDef root stroke-width:3px\n")
+	if opts.Status != nil {
+		b.WriteString("\tclassDef succeeded fill:#c8e6c9,stroke:#2e7d32\n")
+		b.WriteString("\tclassDef failed fill:#ffcdd2,stroke:#c62828\n")
+		b.WriteString("\tclassDef skipped fill:#eeeeee,stroke:#9e9e9e\n")
+	}
+
+	for _, n := range d.nodes {
+		if n.root {
+			fmt.Fprintf(&b, "\tclass n%d root\n", n.node.Id())
+		}
+
+		if class, ok := statusClasses[opts.Status[n.node.Id()]]; ok {
+			fmt.Fprintf(&b, "\tclass n%d %s\n", n.node.Id(), class)
+		}
+	}
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("writing mermaid flowchart: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A RegisterOption configures the registration of a linker constructor
+type RegisterOption func(*registration)
+
+type registration struct {
+	constructor        LinkerJSONConstructor
+	contextConstructor LinkerJSONContextConstructor
+	options            reflect.Type
+	signature          *Signature
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// WithOptions declares the type of the options that a linker constructor
+// expects, using a struct value or a pointer to one. Before the constructor is
+// called, the options in the document are checked against the struct's
+// fields, matched the same way encoding/json does. Fields that do not exist in
+// the struct are reported as unknown, and fields tagged with
+// `graph:"required"` are reported if they are missing. Nested structs, as well
+// as slices and maps of structs, are checked as well.
+func WithOptions(prototype interface{}) RegisterOption {
+	return func(r *registration) {
+		r.options = reflect.TypeOf(prototype)
+	}
+}
+
+// validateOptions checks the raw options against the given type, returning an
+// error for each unknown or missing field. The path is the json pointer of the
+// options within the document
+func validateOptions(t reflect.Type, raw json.RawMessage, path string) (errs []*DocumentError) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if len(raw) == 0 || string(raw) == "null" {
+			object = map[string]json.RawMessage{}
+		} else if err := json.Unmarshal(raw, &object); err != nil {
+			return nil
+		}
+
+		fields := structFields(t)
+		for _, key := range sortedKeys(object) {
+			f, ok := findField(fields, key)
+			if !ok {
+				errs = append(errs, &DocumentError{Path: jsonPointer(path, key), Err: ErrUnknownOption})
+				continue
+			}
+
+			errs = append(errs, validateOptions(f.Type, object[key], jsonPointer(path, key))...)
+		}
+
+		for _, f := range fields {
+			if !f.required {
+				continue
+			}
+
+			found := false
+			for key := range object {
+				if strings.EqualFold(key, f.name) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				errs = append(errs, &DocumentError{Path: jsonPointer(path, f.name), Err: ErrMissingOption})
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var array []json.RawMessage
+		if err := json.Unmarshal(raw, &array); err != nil {
+			return nil
+		}
+
+		for i, value := range array {
+			errs = append(errs, validateOptions(t.Elem(), value, jsonPointer(path, strconv.Itoa(i)))...)
+		}
+	case reflect.Map:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil
+		}
+
+		for _, key := range sortedKeys(object) {
+			errs = append(errs, validateOptions(t.Elem(), object[key], jsonPointer(path, key))...)
+		}
+	}
+
+	return errs
+}
+
+type optionField struct {
+	reflect.StructField
+	name     string
+	required bool
+}
+
+// structFields returns the fields of a struct type, as seen by encoding/json.
+// The fields of embedded structs without a json name are promoted
+func structFields(t reflect.Type) (fields []optionField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := f.Name
+		if n := strings.Split(tag, ",")[0]; n != "" {
+			name = n
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && ft.Kind() == reflect.Struct && strings.Split(tag, ",")[0] == "" {
+			fields = append(fields, structFields(ft)...)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		fields = append(fields, optionField{StructField: f, name: name,
+			required: f.Tag.Get("graph") == "required"})
+	}
+
+	return fields
+}
+
+func findField(fields []optionField, key string) (optionField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+
+	return optionField{}, false
+}
+
+func sortedKeys(object map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// jsonPointer appends the given reference tokens to a json pointer, escaping
+// them as needed
+func jsonPointer(path string, tokens ...string) string {
+	for _, t := range tokens {
+		t = strings.Replace(t, "~", "~0", -1)
+		t = strings.Replace(t, "/", "~1", -1)
+		path += "/" + t
+	}
+
+	return path
+}
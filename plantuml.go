@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var plantUMLEscaper = strings.NewReplacer(`"`, "'", "\n", `\n`)
+
+var statusColors = map[NodeStatus]string{
+	StatusSucceeded: "#C8E6C9",
+	StatusFailed:    "#FFCDD2",
+	StatusSkipped:   "#EEEEEE",
+}
+
+// WritePlantUML writes the graph that would be walked from the given starting
+// linker as a PlantUML activity diagram. Nodes are labelled using the Labeler
+// interface, or by their ids, and root nodes are connected to the start of
+// the diagram. Each edge is labelled with the names of the output and input
+// connectors it connects. If the options hold the status of a walk, nodes are
+// coloured by their status.
+func WritePlantUML(w io.Writer, start Linker, opts DiagramOptions) error {
+	d := newDiagram(start)
+
+	var b bytes.Buffer
+
+	b.WriteString("@startuml\n")
+
+	if opts.Status != nil {
+		b.WriteString("skinparam activity {\n")
+		for _, s := range []NodeStatus{StatusSucceeded, StatusFailed, StatusSkipped} {
+			fmt.Fprintf(&b, "\tBackgroundColor<<%s>> %s\n", statusClasses[s], statusColors[s])
+		}
+		b.WriteString("}\n")
+	}
+
+	// A node is declared the first time it is the target of an arrow, with
+	// every later arrow referring to it by its alias
+	declared := make(map[Id]bool)
+	activity := func(n diagramNode) string {
+		if declared[n.node.Id()] {
+			return fmt.Sprintf("n%d", n.node.Id())
+		}
+		declared[n.node.Id()] = true
+
+		s := fmt.Sprintf("\"%s\" as n%d", plantUMLEscaper.Replace(n.label), n.node.Id())
+		if class, ok := statusClasses[opts.Status[n.node.Id()]]; ok {
+			s += fmt.Sprintf(" <<%s>>", class)
+		}
+
+		return s
+	}
+
+	nodes := make(map[Id]diagramNode, len(d.nodes))
+	for _, n := range d.nodes {
+		nodes[n.node.Id()] = n
+	}
+
+	for _, n := range d.nodes {
+		if n.root {
+			fmt.Fprintf(&b, "(*) --> %s\n", activity(n))
+		}
+	}
+
+	for _, e := range d.edges {
+		from := nodes[e.from]
+		if !declared[e.from] {
+			fmt.Fprintf(&b, "%s\n", activity(from))
+		}
+
+		fmt.Fprintf(&b, "n%d --> [%s -> %s] %s\n", e.from,
+			plantUMLEscaper.Replace(string(e.output)), plantUMLEscaper.Replace(string(e.input)),
+			activity(nodes[e.to]))
+	}
+
+	b.WriteString("@enduml\n")
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("writing plantuml diagram: %v", err)
+	}
+
+	return nil
+}
@@ -2,13 +2,15 @@ package graph
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
-	"strings"
-	"sync"
+	"sort"
+	"strconv"
 	"text/template"
 )
 
@@ -16,77 +18,184 @@ import (
 // raw json message
 type LinkerJSONConstructor func(opts json.RawMessage) (Linker, error)
 
+// A LinkerJSONContextConstructor is like a LinkerJSONConstructor, but it also
+// receives the context of the document that is being processed. Constructors
+// that process documents of their own, such as the ones of subgraphs, may pass
+// it on to ProcessJSONReferencesContext, so that the values attached to it
+// reach the constructors of the nested documents.
+type LinkerJSONContextConstructor func(ctx context.Context, opts json.RawMessage) (Linker, error)
+
+// An ExpandingLinker is a Linker that stands for an inner graph, such as a
+// subgraph. If it is expanded, ProcessJSON replaces it with the linkers of the
+// inner graph, connecting them instead of it.
+type ExpandingLinker interface {
+	Linker
+
+	// Expanded reports whether the linker is to be replaced by its inner graph
+	Expanded() bool
+	// Port returns the inner linker and connector that replace the linker's
+	// connector with the given name and type
+	Port(name ConnectorName, kind ConnectorType) (Endpoint, bool)
+	// Roots returns the roots of the inner graph
+	Roots() []Linker
+}
+
 // The JSONTemplateData is the payload used by ProcessJSON when dealing with
 // text/template data
 type JSONTemplateData struct {
+	// Args are positional arguments, available as .Args
 	Args []string
+	// Vars are named variables, available as .Vars, or through the var and
+	// required template functions
+	Vars map[string]interface{}
 }
 
-var (
-	operationsMu sync.Mutex
-	operations   = make(map[string]LinkerJSONConstructor)
-)
-
-// RegisterLinker allows the creation of Linkers by the given name. If it is
-// called twice by the same name, or if the constructor is nil, it panics
-func RegisterLinker(name string, constructor LinkerJSONConstructor) {
-	operationsMu.Lock()
-	defer operationsMu.Unlock()
-
-	if constructor == nil {
-		panic("drawgl: Register operation constructor is nil")
-	}
-
-	if _, dup := operations[name]; dup {
-		panic("drawgl: Register called twice for constructor " + name)
+// RegisterLinker allows the creation of Linkers by the given name, using the
+// DefaultRegistry. If it is called twice by the same name, if the constructor
+// is nil, or if any of the options is invalid, it panics
+func RegisterLinker(name string, constructor LinkerJSONConstructor, opts ...RegisterOption) {
+	if err := DefaultRegistry.Register(name, constructor, opts...); err != nil {
+		panic(fmt.Sprintf("graph: registering constructor %s: %v", name, err))
 	}
-
-	operations[name] = constructor
 }
 
 type jsonLinker struct {
 	// The registered name of the linker
 	Name string `json:"name,omitempty"`
 	// A linker with this reference id to connect to
-	ReferenceTo uint16 `json:"referenceTo,omitempty"`
+	ReferenceTo jsonReference `json:"referenceTo,omitempty"`
 	// A reference id, used to connect a separate branch to a linker
-	ReferenceId uint16 `json:"referenceId,omitempty"`
+	ReferenceId jsonReference `json:"referenceId,omitempty"`
 	// The constructor options for this linker
 	Options json.RawMessage `json:"options,omitempty"`
 	// The input connector name. If empty, the default name is used
 	Input ConnectorName `json:"input,omitempty"`
 	// A map of all child linkers that are connected to the corresponding
 	// output connector names
-	Outputs map[ConnectorName]jsonLinker `json:"outputs,omitempty"`
+	Outputs map[ConnectorName]jsonOutputs `json:"outputs,omitempty"`
+}
+
+// jsonReference is the reference id of a json linker. It is written as a
+// string, though numbers are accepted as well
+type jsonReference string
+
+// jsonOutputs holds the child linkers that are connected to a single output
+// connector. A single child is represented by a json object, while several
+// children are represented by an array of objects
+type jsonOutputs []jsonLinker
+
+// jsonGraph is the flat representation of a graph, as a list of nodes and the
+// edges between them
+type jsonGraph struct {
+	// All nodes of the graph
+	Nodes []jsonNode `json:"nodes"`
+	// All connections between the nodes
+	Edges []jsonEdge `json:"edges,omitempty"`
+}
+
+type jsonNode struct {
+	// The id of the node, used by the edges
+	Id string `json:"id"`
+	// The registered name of the linker
+	Name string `json:"name"`
+	// The constructor options for this linker
+	Options json.RawMessage `json:"options,omitempty"`
 }
 
-type convertError struct {
-	linker jsonLinker
-	err    error
+type jsonEdge struct {
+	// The id of the parent node
+	From string `json:"from"`
+	// The output connector name. If empty, the default name is used
+	FromConnector ConnectorName `json:"fromConnector,omitempty"`
+	// The id of the child node
+	To string `json:"to"`
+	// The input connector name. If empty, the default name is used
+	ToConnector ConnectorName `json:"toConnector,omitempty"`
 }
 
 type deferredLinker struct {
 	linker     Linker
 	outputName ConnectorName
 	inputName  ConnectorName
+	path       string
+	file       *jsonFile
+}
+
+// jsonProcessor holds the state of a single ProcessJSON call
+type jsonProcessor struct {
+	ctx          context.Context
+	registry     *Registry
+	references   map[jsonReference]Linker
+	deferred     map[jsonReference][]deferredLinker
+	file         *jsonFile
+	errs         DocumentErrors
+	fsys         fs.FS
+	templateData *JSONTemplateData
+	including    map[string]bool
+	included     map[string]bool
+}
+
+// jsonFile is a single document that is processed by a jsonProcessor
+type jsonFile struct {
+	// The name of the file within the file system, if any
+	name    string
+	dec     DocumentDecoder
+	sources []DocumentRoot
+	// The complete json text of the document, if available
+	text []byte
 }
 
-// ProcessJSON converts the input into a graph and returns the root linkers, or
-// an error. The input may be a string, byte array, io.Reader, or
+func newJSONProcessor(reg *Registry) *jsonProcessor {
+	return &jsonProcessor{
+		ctx:        context.Background(),
+		registry:   reg,
+		references: make(map[jsonReference]Linker),
+		deferred:   make(map[jsonReference][]deferredLinker),
+		including:  make(map[string]bool),
+		included:   make(map[string]bool),
+	}
+}
+
+// ProcessJSON converts the input into a graph using the DefaultRegistry, and
+// returns the root linkers, or an error. The input may be a string, byte array, io.Reader, or
 // *json.Decoder. Any other type will cause a panic. If the input is not a json
 // decoder, and JSONTemplateData is not nil, the input is parsed using
-// text/template. JSONTemplateData serves as the payload when parsing.
+// text/template. JSONTemplateData serves as the payload when parsing. Besides
+// the positional .Args, the template may use the named .Vars, along with the
+// following functions:
+//
+//	json      encodes its argument as json, quoting and escaping strings
+//	env       returns the value of an environment variable
+//	var       returns a variable, or nil if it is not defined
+//	required  returns a variable, which has to be defined
+//	default   returns its second argument, or the first one if it is empty
+//
+// Variables that are used with required are checked before the template is
+// executed, and each missing one is reported as a *DocumentError, with its
+// position within the template, wrapping ErrMissingParameter.
+//
+// {
+// 	"Name": "Save",
+// 	"Options": {
+// 		"Path": {{ required "output" | json }},
+// 		"Quality": {{ var "quality" | default 90 | json }},
+// 		"Cache": {{ env "CACHE_DIR" | default "/tmp" | json }}
+// 	}
+// }
 //
 // The input is a list (not a json array) or one or more graph roots. A linker
 // is represented using a json object. The "Name" property holds the name of a
 // registered Linker type, and the "Options" value is passed to the constructor
 // function. It contains an object of "Outputs", each key being an output
-// connector name, and the value being the connected Linker. A json linker may
+// connector name, and the value being the connected Linker, or an array of
+// Linkers if the output connector feeds more than one. A json linker may
 // contain a "ReferenceId", which may be used by another linker to link to it
 // (useful when representing a separate branch). In such a case, the parent
 // linker in the separate branch will have a linker is defined by
 // "ReferenceTo", as oppsosed to a "Name" and "Options". The "ReferenceTo"
-// value corresponds to the "ReferenceId" value of the joining linker. Finally,
+// value corresponds to the "ReferenceId" value of the joining linker. Reference
+// ids are strings, though numbers are accepted as well, and have to be unique
+// within the input. Every "ReferenceTo" has to match a "ReferenceId". Finally,
 // a json linker may contain an "Input" property, which designes the input
 // connector its parent is connected to. It may be omitted when the default is
 // used.
@@ -101,7 +210,7 @@ type deferredLinker struct {
 // 			"Name": "Convolution",
 // 			"Options": {
 // 				"Kernel": [-1, -1, -1, -1, 8, -1, -1, -1, -1],
-// 				"Noralize": true
+// 				"Normalize": true
 // 			},
 // 			"Outputs": {
 // 				"Output": {
@@ -114,33 +223,66 @@ type deferredLinker struct {
 // 		}
 // 	}
 // }
+//
+// Alternatively, a graph may be represented by a single json object with a
+// flat list of "Nodes" and "Edges", which is detected automatically. Each node
+// has a string "Id", as well as the "Name" and "Options" of a registered
+// Linker type. Each edge connects the "FromConnector" output connector of the
+// node with the "From" id to the "ToConnector" input connector of the node
+// with the "To" id. The connector names may be omitted when the defaults are
+// used. The roots of such a graph are the nodes that are not the target of
+// any edge.
+//
+// {
+// 	"Nodes": [
+// 		{"Id": "load", "Name": "Load", "Options": {"Path": "/tmp/in.png"}},
+// 		{"Id": "save", "Name": "Save", "Options": {"Path": "/tmp/out.png"}}
+// 	],
+// 	"Edges": [
+// 		{"From": "load", "To": "save"}
+// 	]
+// }
+//
+// If the input cannot be converted, the returned error is a DocumentErrors,
+// holding a *DocumentError for every problem that was found, with the json
+// pointer path of the offending value and its position within the input. When
+// the input is parsed using text/template, positions refer to the output of
+// the template.
 func ProcessJSON(input interface{}, templateData *JSONTemplateData) (roots []Linker, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if ce, ok := r.(convertError); ok {
-				roots = []Linker{}
-				err = fmt.Errorf("processing json linker data for node '%#v': %v", ce.linker, ce.err)
-			} else {
-				panic(r)
-			}
-		}
-	}()
+	return DefaultRegistry.ProcessJSON(input, templateData)
+}
+
+// ProcessJSON converts the input into a graph, using the constructors of the
+// registry. The input is processed as described by the package-level
+// ProcessJSON function
+func (reg *Registry) ProcessJSON(input interface{}, templateData *JSONTemplateData) (roots []Linker, err error) {
+	roots, _, err = reg.ProcessJSONReferences(input, templateData)
+	return
+}
 
-	var jsonInput string
+// ProcessJSONReferences is like ProcessJSON, but it also returns the linkers
+// that were defined with a "ReferenceId", by their reference id
+func (reg *Registry) ProcessJSONReferences(input interface{}, templateData *JSONTemplateData) (roots []Linker, references map[string]Linker, err error) {
+	return reg.ProcessJSONReferencesContext(context.Background(), input, templateData)
+}
+
+// ProcessJSONReferencesContext is like ProcessJSONReferences, but it passes
+// the given context to the constructors that were registered with
+// RegisterContext
+func (reg *Registry) ProcessJSONReferencesContext(ctx context.Context, input interface{}, templateData *JSONTemplateData) (roots []Linker, references map[string]Linker, err error) {
 	var dec *json.Decoder
+	var text []byte
 
 	switch t := input.(type) {
 	case string:
-		jsonInput = t
+		text = []byte(t)
 	case []byte:
-		jsonInput = string(t)
+		text = t
 	case io.Reader:
-		var b []byte
-		if b, err = ioutil.ReadAll(t); err != nil {
+		if text, err = ioutil.ReadAll(t); err != nil {
 			err = fmt.Errorf("reading json from reader: %v", err)
 			return
 		}
-		jsonInput = string(b)
 	case *json.Decoder:
 		dec = t
 	default:
@@ -148,112 +290,502 @@ func ProcessJSON(input interface{}, templateData *JSONTemplateData) (roots []Lin
 	}
 
 	if dec == nil {
-		var r io.Reader
+		if text, err = executeTemplate(text, templateData); err != nil {
+			if _, ok := err.(DocumentErrors); ok {
+				roots = []Linker{}
+			}
+			return
+		}
+		dec = json.NewDecoder(bytes.NewReader(text))
+	}
 
-		if templateData == nil {
-			r = strings.NewReader(jsonInput)
-		} else {
-			var t *template.Template
+	p := newJSONProcessor(reg)
+	p.ctx = ctx
+	roots = p.processFile(&jsonFile{dec: &jsonDocument{dec: dec}, text: text})
 
-			if t, err = template.New("json").Parse(jsonInput); err != nil {
-				err = fmt.Errorf("parsing template: %v", err)
-				return
-			}
+	return p.result(roots)
+}
 
-			var b bytes.Buffer
-			if err = t.Execute(&b, templateData); err != nil {
-				err = fmt.Errorf("executing template: %v", err)
-				return
-			}
+// executeTemplate parses the text using text/template, if the template data
+// is not nil, and returns the output of the template. Missing required
+// parameters are reported as DocumentErrors
+func executeTemplate(text []byte, templateData *JSONTemplateData) ([]byte, error) {
+	if templateData == nil {
+		return text, nil
+	}
 
-			r = &b
+	t, err := template.New("json").Funcs(templateFuncs(templateData)).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %v", err)
+	}
+
+	if errs := missingParameters(t, templateData); len(errs) > 0 {
+		for _, e := range errs {
+			e.setPosition(text)
 		}
-		dec = json.NewDecoder(r)
+
+		return nil, errs
 	}
 
-	var references = make(map[uint16]Linker)
-	var deferred = make(map[uint16][]deferredLinker)
+	var b bytes.Buffer
+	if err = t.Execute(&b, templateData); err != nil {
+		return nil, fmt.Errorf("executing template: %w", err)
+	}
 
-	for {
-		var root jsonLinker
-		if err = dec.Decode(&root); err != nil {
-			if err == io.EOF {
-				err = nil
-				break
-			} else {
-				panic(convertError{linker: jsonLinker{}, err: fmt.Errorf("decoding root: %v", err)})
+	return b.Bytes(), nil
+}
+
+// result reports any unresolved references, and returns either the roots and
+// references, or the errors of the processed documents
+func (p *jsonProcessor) result(roots []Linker) ([]Linker, map[string]Linker, error) {
+	p.unresolved()
+
+	if len(p.errs) > 0 {
+		return []Linker{}, nil, p.errs
+	}
+
+	references := make(map[string]Linker, len(p.references))
+	for ref, l := range p.references {
+		references[string(ref)] = l
+	}
+
+	return roots, references, nil
+}
+
+// processFile converts the roots of the decoded document into a graph.
+// Decoding stops at the first error returned by the decoder
+func (p *jsonProcessor) processFile(f *jsonFile) (roots []Linker) {
+	parent := p.file
+	p.file = f
+	defer func() { p.file = parent }()
+
+	for i := 0; ; i++ {
+		path := jsonPointer("", strconv.Itoa(i))
+
+		doc, err := f.dec.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			derr, ok := err.(*DocumentError)
+			if !ok {
+				derr = &DocumentError{Err: fmt.Errorf("decoding root: %w", err)}
 			}
+			// The path of a decoding error is relative to its root
+			derr.Path = path + derr.Path
+			p.report(f, derr)
+			break
 		}
 
-		r, rId := jsonToLinker(root, references)
-		if rId > 0 {
-			panic(convertError{linker: jsonLinker{}, err: errors.New("roots cannot be references")})
+		f.sources = append(f.sources, doc)
+
+		var inc jsonInclude
+		if err := json.Unmarshal(doc.JSON, &inc); err == nil && inc.Include != "" {
+			roots = append(roots, p.include(inc.Include, path)...)
+			continue
 		}
 
-		processLinkerTree(r, root, references, deferred)
-		roots = append(roots, r)
+		var g jsonGraph
+		if err := json.Unmarshal(doc.JSON, &g); err == nil && g.Nodes != nil {
+			roots = append(roots, p.processGraph(g, path)...)
+			continue
+		}
+
+		var root jsonLinker
+		if err := json.Unmarshal(doc.JSON, &root); err != nil {
+			p.fail(path, "", fmt.Errorf("decoding root: %w", err))
+			continue
+		}
+
+		if root.ReferenceTo != "" {
+			p.fail(path, root.Name, ErrReferenceRoot)
+			continue
+		}
+
+		r, _ := p.jsonToLinker(root, path)
+		if r != nil {
+			p.resolveDeferred(r, root)
+		}
+
+		p.processLinkerTree(r, root, path)
+		if r != nil {
+			roots = append(roots, expandRoots(r)...)
+		}
 	}
 
-	return
+	return roots
 }
 
-func processLinkerTree(p Linker, rj jsonLinker, references map[uint16]Linker, deferred map[uint16][]deferredLinker) {
-	for name, cj := range rj.Outputs {
-		c, ref := jsonToLinker(cj, references)
+// unresolved reports every linker whose reference was never defined
+func (p *jsonProcessor) unresolved() {
+	refs := make([]jsonReference, 0, len(p.deferred))
+	for ref := range p.deferred {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
 
-		inputName := InputName
-		if cj.Input != "" {
-			inputName = cj.Input
+	for _, ref := range refs {
+		for _, op := range p.deferred[ref] {
+			p.report(op.file, &DocumentError{Path: jsonPointer(op.path, "referenceTo"),
+				Err: fmt.Errorf("%s: %w", ref, ErrUnresolvedReference)})
 		}
+	}
+}
 
-		if c != nil {
-			if ops, ok := deferred[cj.ReferenceId]; ok {
-				for _, op := range ops {
-					opInputName := InputName
-					if op.inputName != "" {
-						opInputName = op.inputName
-					}
+// fail records an error for the value with the given json pointer path
+// within the current document. The name is the registered name of the
+// offending linker, if known
+func (p *jsonProcessor) fail(path, name string, err error) {
+	p.report(p.file, &DocumentError{Path: path, Name: name, Err: err})
+}
 
-					op.linker.Connect(c, op.linker.Connector(op.outputName, OutputType), c.Connector(opInputName, InputType))
-				}
+// report records the error, locating its path within the given document
+func (p *jsonProcessor) report(f *jsonFile, e *DocumentError) {
+	if f == nil {
+		p.errs = append(p.errs, e)
+		return
+	}
+
+	e.File = f.name
 
-				delete(deferred, cj.ReferenceId)
+	if tokens := splitPointer(e.Path); len(tokens) > 0 {
+		if i, err := strconv.Atoi(tokens[0]); err == nil && i < len(f.sources) {
+			if doc, ok := f.dec.(*jsonDocument); ok {
+				e.Offset = doc.offsets[i] + locate(f.sources[i].JSON, tokens[1:])
+			} else if position := f.sources[i].Position; position != nil {
+				e.Line, e.Column = position(jsonPointer("", tokens[1:]...))
 			}
-			p.Connect(c, p.Connector(name, OutputType), c.Connector(inputName, InputType))
+		}
+	}
 
-			processLinkerTree(c, cj, references, deferred)
-		} else if ref > 0 {
-			deferred[ref] = append(deferred[ref], deferredLinker{linker: p, outputName: name, inputName: inputName})
-		} else {
-			panic(convertError{linker: cj, err: errors.New("no child linker or reference id")})
+	if f.text != nil {
+		e.setPosition(f.text)
+	}
+
+	p.errs = append(p.errs, e)
+}
+
+// processGraph converts a flat graph into linkers, returning the ones that
+// have no incoming edges, in the order of their definition
+func (p *jsonProcessor) processGraph(g jsonGraph, path string) (roots []Linker) {
+	linkers := make(map[string]Linker, len(g.Nodes))
+	names := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		npath := jsonPointer(path, "nodes", strconv.Itoa(i))
+
+		if n.Id == "" {
+			p.fail(npath, n.Name, errors.New("node without an id"))
+			continue
+		}
+
+		if _, ok := names[n.Id]; ok {
+			p.fail(jsonPointer(npath, "id"), n.Name, fmt.Errorf("duplicate node id %q", n.Id))
+			continue
+		}
+
+		linkers[n.Id], _ = p.jsonToLinker(jsonLinker{Name: n.Name, Options: n.Options}, npath)
+		names[n.Id] = n.Name
+	}
+
+	children := make(map[string]bool)
+	for i, e := range g.Edges {
+		epath := jsonPointer(path, "edges", strconv.Itoa(i))
+
+		if _, ok := names[e.From]; !ok {
+			p.fail(jsonPointer(epath, "from"), "", fmt.Errorf("edge %s -> %s: unknown node id", e.From, e.To))
+			continue
+		}
+
+		if _, ok := names[e.To]; !ok {
+			p.fail(jsonPointer(epath, "to"), "", fmt.Errorf("edge %s -> %s: unknown node id", e.From, e.To))
+			continue
+		}
+
+		children[e.To] = true
+
+		output, input := OutputName, InputName
+		if e.FromConnector != "" {
+			output = e.FromConnector
+		}
+		if e.ToConnector != "" {
+			input = e.ToConnector
+		}
+
+		ok := p.checkConnector(names[e.From], output, OutputType, jsonPointer(epath, "fromConnector"))
+		ok = p.checkConnector(names[e.To], input, InputType, jsonPointer(epath, "toConnector")) && ok
+
+		if from, to := linkers[e.From], linkers[e.To]; ok && from != nil && to != nil {
+			p.connect(from, output, to, input, epath, names[e.To])
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if l := linkers[n.Id]; l != nil && !children[n.Id] {
+			roots = append(roots, expandRoots(l)...)
+		}
+	}
+
+	return roots
+}
+
+// processLinkerTree processes the children of the json linker. If the parent
+// could not be constructed, the children are still processed in order to
+// report their errors, but are not connected
+func (p *jsonProcessor) processLinkerTree(parent Linker, rj jsonLinker, path string) {
+	names := make([]ConnectorName, 0, len(rj.Outputs))
+	for name := range rj.Outputs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	for _, name := range names {
+		opath := jsonPointer(path, "outputs", string(name))
+
+		source := parent
+		if !p.checkConnector(rj.Name, name, OutputType, opath) {
+			source = nil
+		}
+
+		for i, cj := range rj.Outputs[name] {
+			cpath := opath
+			if len(rj.Outputs[name]) > 1 {
+				cpath = jsonPointer(cpath, strconv.Itoa(i))
+			}
+
+			p.processLinkerChild(source, name, cj, cpath)
+		}
+	}
+}
+
+func (p *jsonProcessor) processLinkerChild(parent Linker, name ConnectorName, cj jsonLinker, path string) {
+	inputName := InputName
+	if cj.Input != "" {
+		inputName = cj.Input
+	}
+
+	if !p.checkConnector(cj.Name, inputName, InputType, jsonPointer(path, "input")) {
+		parent = nil
+	}
+
+	c, ref := p.jsonToLinker(cj, path)
+
+	if c != nil {
+		p.resolveDeferred(c, cj)
+
+		if parent != nil {
+			p.connect(parent, name, c, inputName, path, cj.Name)
+		}
+
+		p.processLinkerTree(c, cj, path)
+	} else if ref != "" {
+		if parent != nil {
+			p.deferred[ref] = append(p.deferred[ref], deferredLinker{linker: parent, outputName: name, inputName: inputName, path: path, file: p.file})
+		}
+	} else {
+		p.processLinkerTree(nil, cj, path)
+	}
+}
+
+// resolveDeferred connects the linkers that referred to the reference id of
+// the json linker before it was constructed
+func (p *jsonProcessor) resolveDeferred(l Linker, j jsonLinker) {
+	ops, ok := p.deferred[j.ReferenceId]
+	if !ok {
+		return
+	}
+
+	// The deferred connections may come from other documents, in which their
+	// errors are reported
+	file := p.file
+	for _, op := range ops {
+		p.file = op.file
+		if p.checkConnector(j.Name, op.inputName, InputType, jsonPointer(op.path, "input")) {
+			p.connect(op.linker, op.outputName, l, op.inputName, op.path, j.Name)
 		}
 	}
+	p.file = file
+
+	delete(p.deferred, j.ReferenceId)
 }
 
-func jsonToLinker(j jsonLinker, references map[uint16]Linker) (Linker, uint16) {
+// checkConnector reports a connector that is not declared in the signature of
+// the linker registered with the given name, returning false. Connectors of
+// linkers without a declared signature are not checked
+func (p *jsonProcessor) checkConnector(name string, connector ConnectorName, kind ConnectorType, path string) bool {
+	if name == "" {
+		return true
+	}
+
+	if sig, ok := p.registry.Signature(name); ok && !sig.hasConnector(connector, kind) {
+		p.fail(path, name, fmt.Errorf("%s: %w", connector, ErrUnknownConnector))
+		return false
+	}
+
+	return true
+}
+
+// connect connects the output connector of the parent to the input connector
+// of the child. The path is the json pointer of the connection within the
+// document, and the name is the registered name of the child
+func (p *jsonProcessor) connect(parent Linker, output ConnectorName, child Linker, input ConnectorName, path, name string) {
+	var ok bool
+	if parent, output, ok = expandPort(parent, output, OutputType); !ok {
+		p.fail(path, name, fmt.Errorf("output %s: %w", output, ErrUnknownConnector))
+		return
+	}
+
+	if child, input, ok = expandPort(child, input, InputType); !ok {
+		p.fail(path, name, fmt.Errorf("input %s: %w", input, ErrUnknownConnector))
+		return
+	}
+
+	source := parent.Connector(output, OutputType)
+	if source == nil {
+		p.fail(path, name, fmt.Errorf("output %s: %w", output, ErrUnknownConnector))
+		return
+	}
+
+	sink := child.Connector(input, InputType)
+	if sink == nil {
+		p.fail(path, name, fmt.Errorf("input %s: %w", input, ErrUnknownConnector))
+		return
+	}
+
+	if err := parent.Connect(child, source, sink); err != nil {
+		p.fail(path, name, fmt.Errorf("connecting %s to %s: %w", output, input, err))
+	}
+}
+
+// expandPort returns the inner linker and connector that replace the
+// connector of an expanded linker. Other linkers are returned as they are
+func expandPort(l Linker, name ConnectorName, kind ConnectorType) (Linker, ConnectorName, bool) {
+	for {
+		el, ok := l.(ExpandingLinker)
+		if !ok || !el.Expanded() {
+			return l, name, true
+		}
+
+		port, ok := el.Port(name, kind)
+		if !ok || port.Linker == nil || port.Connector == nil {
+			return l, name, false
+		}
+
+		l, name = port.Linker, port.Connector.Name()
+	}
+}
+
+// expandRoots returns the roots of the inner graph of an expanded linker, or
+// the linker itself
+func expandRoots(l Linker) []Linker {
+	el, ok := l.(ExpandingLinker)
+	if !ok || !el.Expanded() {
+		return []Linker{l}
+	}
+
+	var roots []Linker
+	for _, r := range el.Roots() {
+		roots = append(roots, expandRoots(r)...)
+	}
+
+	return roots
+}
+
+func (r *jsonReference) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*r = jsonReference(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("reference id %s is neither a string nor a number", b)
+	}
+
+	*r = jsonReference(n)
+	return nil
+}
+
+func (o *jsonOutputs) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, (*[]jsonLinker)(o))
+	}
+
+	var l jsonLinker
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+
+	*o = jsonOutputs{l}
+	return nil
+}
+
+func (o jsonOutputs) MarshalJSON() ([]byte, error) {
+	if len(o) == 1 {
+		return json.Marshal(o[0])
+	}
+
+	return json.Marshal([]jsonLinker(o))
+}
+
+// jsonToLinker constructs the linker described by the json linker, or
+// returns the linker it references. If the referenced linker hasn't been
+// constructed yet, its reference id is returned instead. The path is the json
+// pointer of the json linker within the document. If the linker cannot be
+// constructed, the error is recorded and neither is returned
+func (p *jsonProcessor) jsonToLinker(j jsonLinker, path string) (Linker, jsonReference) {
 	if j.Name != "" {
-		c := operations[j.Name]
-		if c == nil {
-			panic(convertError{linker: j, err: fmt.Errorf("unknown name %s", j.Name)})
+		reg, ok := p.registry.lookup(j.Name)
+		if !ok {
+			p.fail(jsonPointer(path, "name"), j.Name, fmt.Errorf("%s: %w", j.Name, ErrUnregisteredName))
+			return nil, ""
+		}
+
+		if reg.options != nil {
+			if errs := validateOptions(reg.options, j.Options, jsonPointer(path, "options")); len(errs) > 0 {
+				for _, e := range errs {
+					e.Name = j.Name
+					p.report(p.file, e)
+				}
+				return nil, ""
+			}
 		}
 
-		l, err := c(j.Options)
+		var l Linker
+		var err error
+		if reg.contextConstructor != nil {
+			l, err = reg.contextConstructor(p.ctx, j.Options)
+		} else {
+			l, err = reg.constructor(j.Options)
+		}
 		if err != nil {
-			panic(convertError{linker: j, err: fmt.Errorf("constructor failed for %s: %v", j.Name, err)})
+			p.fail(path, j.Name, fmt.Errorf("constructor failed for %s: %w", j.Name, err))
+			return nil, ""
+		}
+
+		if reg.signature != nil {
+			if err := reg.signature.check(l); err != nil {
+				p.fail(path, j.Name, fmt.Errorf("constructor for %s: %w", j.Name, err))
+				return nil, ""
+			}
 		}
 
-		if j.ReferenceId != 0 {
-			references[j.ReferenceId] = l
+		if j.ReferenceId != "" {
+			if _, ok := p.references[j.ReferenceId]; ok {
+				p.fail(jsonPointer(path, "referenceId"), j.Name, fmt.Errorf("%s: %w", j.ReferenceId, ErrDuplicateReference))
+			} else {
+				p.references[j.ReferenceId] = l
+			}
 		}
 
-		return l, 0
-	} else if j.ReferenceTo != 0 {
-		if l, ok := references[j.ReferenceTo]; ok {
-			return l, 0
+		return l, ""
+	} else if j.ReferenceTo != "" {
+		if l, ok := p.references[j.ReferenceTo]; ok {
+			return l, ""
 		} else {
 			return nil, j.ReferenceTo
 		}
 	} else {
-		panic(convertError{linker: j, err: fmt.Errorf("json linker contains no name or link target")})
+		p.fail(path, "", ErrNoNameOrReference)
+		return nil, ""
 	}
 }
@@ -2,6 +2,7 @@ package graph_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -218,6 +219,72 @@ func TestProcessJSONTemplate(t *testing.T) {
 	}
 }
 
+func TestProcessJSONTemplateVars(t *testing.T) {
+	t.Setenv("GRAPH_TEST_DIR", "/var/tmp")
+
+	roots, err := graph.ProcessJSON(testTemplateVars, &graph.JSONTemplateData{
+		Vars: map[string]interface{}{"input": `in "quoted".png`},
+	})
+	if err != nil {
+		t.Fatalf("processing testTemplateVars: %v", err)
+	}
+
+	if n := roots[0].Node().(loadNode); n.opts.Path != `in "quoted".png` {
+		t.Fatalf("Expected %s, got %s\n", `in "quoted".png`, n.opts.Path)
+	}
+
+	save := roots[0].Connector(graph.OutputName, graph.OutputType).Targets()[0].Linker
+	if n := save.Node().(saveNode); n.opts.Path != "/var/tmp/out.png" {
+		t.Fatalf("Expected %s, got %s\n", "/var/tmp/out.png", n.opts.Path)
+	}
+
+	_, err = graph.ProcessJSON(testTemplateVars, &graph.JSONTemplateData{})
+
+	var derr *graph.DocumentError
+	if !errors.Is(err, graph.ErrMissingParameter) || !errors.As(err, &derr) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrMissingParameter, err)
+	}
+
+	if derr.Line != 4 {
+		t.Fatalf("Expected %v, got %v\n", 4, derr.Line)
+	}
+}
+
+func TestProcessJSONFlat(t *testing.T) {
+	roots, err := graph.ProcessJSON(testFlat, nil)
+	if err != nil {
+		t.Fatalf("processing testFlat: %v", err)
+	}
+
+	if len(roots) != 2 {
+		t.Fatalf("Expected 2 roots, got %d", len(roots))
+	}
+
+	for i, path := range []string{"1", "2"} {
+		if n, ok := roots[i].Node().(loadNode); !ok || n.opts.Path != path {
+			t.Fatalf("Unexpected root node %#v", roots[i].Node())
+		}
+	}
+
+	targets := roots[0].Connector(graph.OutputName, graph.OutputType).Targets()
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+
+	save := roots[1].Connector("ref", graph.OutputType)
+	if target, ic := save.Target(); target == nil || ic.Name() != "dup" {
+		t.Fatalf("Expected a connection to %s, got %v", "dup", ic)
+	} else if _, ok := target.Node().(saveNode); !ok {
+		t.Fatalf("Unknown node type %T", target.Node())
+	}
+
+	for _, data := range []string{testFlatUnknownId, testFlatDuplicateId, testFlatUnknownConnector} {
+		if _, err := graph.ProcessJSON(data, nil); err == nil {
+			t.Fatalf("Expected an error for %s", data)
+		}
+	}
+}
+
 type loadNode struct {
 	graph.Node
 	opts loadOptions
@@ -238,6 +305,112 @@ type passNode struct {
 	graph.Node
 }
 
+func TestProcessJSONErrors(t *testing.T) {
+	_, err := graph.ProcessJSON(testErrors, nil)
+
+	var errs graph.DocumentErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected document errors, got %v\n", err)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("Expected %v, got %v\n", 2, len(errs))
+	}
+
+	if !errors.Is(err, graph.ErrUnregisteredName) || !errors.Is(err, graph.ErrUnknownConnector) {
+		t.Fatalf("Expected %v and %v, got %v\n", graph.ErrUnregisteredName, graph.ErrUnknownConnector, err)
+	}
+
+	expected := []graph.DocumentError{
+		{Path: "/1/outputs/Output/name", Line: 7, Column: 4, Name: "Missing"},
+		{Path: "/1/outputs/ref", Line: 9, Column: 3, Name: "Save"},
+	}
+
+	for i, e := range expected {
+		if errs[i].Path != e.Path || errs[i].Line != e.Line || errs[i].Column != e.Column || errs[i].Name != e.Name {
+			t.Fatalf("Expected %v, got %v\n", e, *errs[i])
+		}
+	}
+
+	var derr *graph.DocumentError
+	if _, err := graph.ProcessJSON(`{"Name": "Pass"}`+"\n"+`{"Name": }`, nil); !errors.As(err, &derr) {
+		t.Fatalf("Expected a document error, got %v\n", err)
+	}
+
+	if derr.Path != "/1" || derr.Line != 2 {
+		t.Fatalf("Expected %v, got %v\n", "/1 at line 2", derr)
+	}
+}
+
+func TestProcessJSONReferences(t *testing.T) {
+	roots, err := graph.ProcessJSON(testReferences, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if len(roots) != 2 {
+		t.Fatalf("Expected %v, got %v\n", 2, len(roots))
+	}
+
+	save := roots[0].Connector(graph.OutputName, graph.OutputType).Targets()[0].Linker
+	if save != roots[1].Connector("ref", graph.OutputType).Targets()[0].Linker {
+		t.Fatalf("Expected both roots to be connected to the same linker\n")
+	}
+
+	roots, err = graph.ProcessJSON(testReferencesForward, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if len(roots) != 2 {
+		t.Fatalf("Expected %v, got %v\n", 2, len(roots))
+	}
+
+	if target, _ := roots[0].Connection(roots[0].Connector(graph.OutputName, graph.OutputType)); target != roots[1] {
+		t.Fatalf("Expected %v, got %v\n", roots[1], target)
+	}
+
+	_, err = graph.ProcessJSON(testReferencesInvalid, nil)
+
+	var errs graph.DocumentErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected document errors, got %v\n", err)
+	}
+
+	expected := []struct {
+		path string
+		err  error
+	}{
+		{"/0/outputs/ref/referenceId", graph.ErrDuplicateReference},
+		{"/1/outputs/Output/referenceTo", graph.ErrUnresolvedReference},
+		{"/1/outputs/ref/referenceTo", graph.ErrUnresolvedReference},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %v, got %v\n", len(expected), err)
+	}
+
+	for i, e := range expected {
+		if errs[i].Path != e.path || !errors.Is(errs[i], e.err) {
+			t.Fatalf("Expected %s: %v, got %v\n", e.path, e.err, errs[i])
+		}
+	}
+}
+
+func (n loadNode) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	opts, err := json.Marshal(n.opts)
+	return "Load", opts, err
+}
+
+func (n saveNode) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	opts, err := json.Marshal(n.opts)
+	return "Save", opts, err
+}
+
+func (n passNode) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	return "Pass", nil, nil
+}
+
 func init() {
 	graph.RegisterLinker("Load", func(opts json.RawMessage) (graph.Linker, error) {
 		var o loadOptions
@@ -282,6 +455,58 @@ func init() {
 }
 
 const (
+	testErrors = `{"Name": "Load", "Options": {"Path": "in.png"}}
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"Output": {
+			"Name": "Missing"
+		},
+		"ref": {"Name": "Save", "Options": {}, "Input": "nope"}
+	}
+}`
+	testReferences = `
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"Output": {"Name": "Save", "Options": {}, "ReferenceId": "save"}
+	}
+}
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"ref": {"ReferenceTo": "save", "Input": "dup"}
+	}
+}`
+	testReferencesForward = `
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"Output": {"ReferenceTo": "save"}
+	}
+}
+{"Name": "Save", "Options": {}, "ReferenceId": "save"}`
+	testReferencesInvalid = `
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"Output": {"Name": "Pass", "ReferenceId": "a"},
+		"ref": {"Name": "Pass", "ReferenceId": "a"}
+	}
+}
+{
+	"Name": "Load",
+	"Options": {"Path": "in.png"},
+	"Outputs": {
+		"Output": {"ReferenceTo": "b"},
+		"ref": {"ReferenceTo": "c"}
+	}
+}`
 	testData1 = `
 {
 	"Name": "Load",
@@ -353,6 +578,55 @@ const (
 	}
 }
 	`
+	testFlat = `
+{
+	"nodes": [
+		{"id": "load1", "name": "Load", "options": {"Path": "1"}},
+		{"id": "load2", "name": "Load", "options": {"Path": "2"}},
+		{"id": "pass", "name": "Pass"},
+		{"id": "save", "name": "Save", "options": {"Path": "3"}}
+	],
+	"edges": [
+		{"from": "load1", "to": "pass"},
+		{"from": "load1", "to": "save"},
+		{"from": "load2", "fromConnector": "ref", "to": "save", "toConnector": "dup"}
+	]
+}
+`
+	testFlatUnknownId = `
+{
+	"nodes": [{"id": "load", "name": "Load", "options": {}}],
+	"edges": [{"from": "load", "to": "save"}]
+}
+`
+	testFlatDuplicateId = `
+{
+	"nodes": [
+		{"id": "load", "name": "Load", "options": {}},
+		{"id": "load", "name": "Pass"}
+	]
+}
+`
+	testFlatUnknownConnector = `
+{
+	"nodes": [
+		{"id": "load", "name": "Load", "options": {}},
+		{"id": "pass", "name": "Pass"}
+	],
+	"edges": [{"from": "load", "to": "pass", "toConnector": "aux"}]
+}
+`
+	testTemplateVars = `
+{
+	"Name": "Load",
+	"Options": {"Path": {{ required "input" | json }}},
+	"Outputs": {
+		"Output": {
+			"Name": "Save",
+			"Options": {"Path": {{ printf "%s/out.png" (env "GRAPH_TEST_DIR" | default "/tmp") | json }}}
+		}
+	}
+}`
 	testTemplate = `
 {
 	"Name": "Load",
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Registry holds the named linker constructors that are used when converting
+// documents into graphs. Separate registries may hold different constructors
+// for the same name
+type Registry struct {
+	mu            sync.RWMutex
+	registrations map[string]registration
+}
+
+var (
+	ErrNilConstructor    = errors.New("The linker constructor is nil")
+	ErrDuplicateName     = errors.New("A linker constructor is already registered with the same name")
+	ErrUnregisteredName  = errors.New("No linker constructor is registered with the given name")
+	ErrReferenceRoot     = errors.New("Roots cannot be references")
+	ErrNoNameOrReference = errors.New("The linker has no name or reference")
+	ErrInvalidOptionType = errors.New("The linker options type is not a struct")
+	ErrUnknownOption     = errors.New("Unknown linker option")
+	ErrMissingOption     = errors.New("Missing required linker option")
+	ErrUnknownConnector  = errors.New("Unknown connector name")
+
+	ErrUnresolvedReference = errors.New("No linker is defined with the given reference id")
+	ErrDuplicateReference  = errors.New("A linker is already defined with the same reference id")
+	ErrMissingParameter    = errors.New("Missing required template parameter")
+	ErrIncludeCycle        = errors.New("The document is already being included")
+	ErrIncludeWithoutFS    = errors.New("Documents can only be included when processing a file system")
+)
+
+// Signature describes the names of the input and output connectors of a
+// registered linker
+type Signature struct {
+	Inputs  []ConnectorName
+	Outputs []ConnectorName
+}
+
+// DefaultRegistry is the registry used by RegisterLinker and ProcessJSON
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{registrations: make(map[string]registration)}
+}
+
+// Register allows the creation of Linkers by the given name. Additional
+// options may describe what the constructor expects, such as WithOptions. It
+// returns an error if the name has already been registered, if the
+// constructor is nil, or if any of the options is invalid
+func (r *Registry) Register(name string, constructor LinkerJSONConstructor, opts ...RegisterOption) error {
+	if constructor == nil {
+		return ErrNilConstructor
+	}
+
+	return r.register(name, registration{constructor: constructor}, opts)
+}
+
+// RegisterContext is like Register, but the constructor also receives the
+// context of the document that is being processed, as given to
+// ProcessJSONReferencesContext. Lookup returns a constructor that is called
+// with a background context
+func (r *Registry) RegisterContext(name string, constructor LinkerJSONContextConstructor, opts ...RegisterOption) error {
+	if constructor == nil {
+		return ErrNilConstructor
+	}
+
+	return r.register(name, registration{
+		constructor: func(opts json.RawMessage) (Linker, error) {
+			return constructor(context.Background(), opts)
+		},
+		contextConstructor: constructor,
+	}, opts)
+}
+
+func (r *Registry) register(name string, reg registration, opts []RegisterOption) error {
+	defer r.mu.Unlock()
+	r.mu.Lock()
+
+	if _, dup := r.registrations[name]; dup {
+		return ErrDuplicateName
+	}
+
+	for _, o := range opts {
+		o(&reg)
+	}
+
+	if reg.options != nil {
+		t := reg.options
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return ErrInvalidOptionType
+		}
+	}
+
+	r.registrations[name] = reg
+	return nil
+}
+
+// Lookup returns the constructor registered with the given name. The boolean
+// result is false if no such constructor exists
+func (r *Registry) Lookup(name string) (LinkerJSONConstructor, bool) {
+	defer r.mu.RUnlock()
+	r.mu.RLock()
+
+	reg, ok := r.registrations[name]
+	return reg.constructor, ok
+}
+
+// Signature returns the connectors declared for the linker registered with
+// the given name. The boolean result is false if no such linker exists, or if
+// its connectors were not declared
+func (r *Registry) Signature(name string) (Signature, bool) {
+	reg, ok := r.lookup(name)
+	if !ok || reg.signature == nil {
+		return Signature{}, false
+	}
+
+	return Signature{
+		Inputs:  append([]ConnectorName{}, reg.signature.Inputs...),
+		Outputs: append([]ConnectorName{}, reg.signature.Outputs...),
+	}, true
+}
+
+func (r *Registry) lookup(name string) (registration, bool) {
+	defer r.mu.RUnlock()
+	r.mu.RLock()
+
+	reg, ok := r.registrations[name]
+	return reg, ok
+}
+
+// Names returns the sorted names of all registered constructors
+func (r *Registry) Names() []string {
+	defer r.mu.RUnlock()
+	r.mu.RLock()
+
+	names := make([]string, 0, len(r.registrations))
+	for name := range r.registrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// WithInputs declares the names of the input connectors of a linker. Once
+// declared, any other input connector name used in a document is reported as
+// an error, before the linker is constructed
+func WithInputs(names ...ConnectorName) RegisterOption {
+	return func(r *registration) {
+		if r.signature == nil {
+			r.signature = &Signature{}
+		}
+		r.signature.Inputs = append(r.signature.Inputs, names...)
+	}
+}
+
+// WithOutputs declares the names of the output connectors of a linker. Once
+// declared, any other output connector name used in a document is reported as
+// an error, before the linker is constructed
+func WithOutputs(names ...ConnectorName) RegisterOption {
+	return func(r *registration) {
+		if r.signature == nil {
+			r.signature = &Signature{}
+		}
+		r.signature.Outputs = append(r.signature.Outputs, names...)
+	}
+}
+
+// hasConnector returns whether the signature declares a connector with the
+// given name and type
+func (s Signature) hasConnector(name ConnectorName, kind ConnectorType) bool {
+	names := s.Inputs
+	if kind == OutputType {
+		names = s.Outputs
+	}
+
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check returns an error if the connectors of the linker do not match the ones
+// declared in the signature
+func (s Signature) check(l Linker) error {
+	for _, kind := range []ConnectorType{InputType, OutputType} {
+		names := s.Inputs
+		if kind == OutputType {
+			names = s.Outputs
+		}
+
+		for _, name := range names {
+			if l.Connector(name, kind) == nil {
+				return fmt.Errorf("declared connector %s is missing", name)
+			}
+		}
+
+		for _, c := range l.Connectors(kind) {
+			if !s.hasConnector(c.Name(), kind) {
+				return fmt.Errorf("connector %s is not declared", c.Name())
+			}
+		}
+	}
+
+	return nil
+}
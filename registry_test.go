@@ -0,0 +1,216 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestRegistry(t *testing.T) {
+	r1, r2 := graph.NewRegistry(), graph.NewRegistry()
+
+	load := func(path string) graph.LinkerJSONConstructor {
+		return func(opts json.RawMessage) (graph.Linker, error) {
+			return base.NewLinkerNode(loadNode{Node: base.NewNode(), opts: loadOptions{Path: path}}), nil
+		}
+	}
+
+	if err := r1.Register("Load", load("r1")); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := r2.Register("Load", load("r2")); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := r1.Register("Load", load("r1")); err != graph.ErrDuplicateName {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrDuplicateName, err)
+	}
+
+	if err := r1.Register("Save", nil); err != graph.ErrNilConstructor {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrNilConstructor, err)
+	}
+
+	if err := r2.Register("Pass", load("r2")); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if _, ok := r1.Lookup("Load"); !ok {
+		t.Fatalf("Expected a Load constructor\n")
+	}
+
+	if _, ok := r1.Lookup("Pass"); ok {
+		t.Fatalf("Unexpected Pass constructor\n")
+	}
+
+	names := r2.Names()
+	if len(names) != 2 || names[0] != "Load" || names[1] != "Pass" {
+		t.Fatalf("Unexpected names %v\n", names)
+	}
+
+	for _, r := range []struct {
+		registry *graph.Registry
+		path     string
+	}{{r1, "r1"}, {r2, "r2"}} {
+		roots, err := r.registry.ProcessJSON(`{"Name": "Load"}`, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if n := roots[0].Node().(loadNode); n.opts.Path != r.path {
+			t.Fatalf("Expected %s, got %s\n", r.path, n.opts.Path)
+		}
+	}
+
+	if _, err := r1.ProcessJSON(`{"Name": "Pass"}`, nil); !errors.Is(err, graph.ErrUnregisteredName) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrUnregisteredName, err)
+	}
+}
+
+func TestRegistryOptions(t *testing.T) {
+	type kernel struct {
+		Size   int `graph:"required"`
+		Values []float64
+	}
+
+	type convolutionOptions struct {
+		Kernel    kernel `json:"kernel" graph:"required"`
+		Normalize bool
+		Channels  []struct {
+			Name string `graph:"required"`
+		}
+		Extra map[string]kernel
+	}
+
+	r := graph.NewRegistry()
+
+	err := r.Register("Convolution", func(opts json.RawMessage) (graph.Linker, error) {
+		return base.NewLinker(), nil
+	}, graph.WithOptions(convolutionOptions{}))
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if _, err := r.ProcessJSON(`{"Name": "Convolution", "Options": {"Kernel": {"Size": 3}, "normalize": true}}`, nil); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	_, err = r.ProcessJSON(`{"Name": "Convolution", "Options": {"Kernel": {"Size": 3}, "Noralize": true}}`, nil)
+	if !errors.Is(err, graph.ErrUnknownOption) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrUnknownOption, err)
+	}
+
+	if !strings.Contains(err.Error(), "/0/options/Noralize") {
+		t.Fatalf("Expected the path of the option in %v\n", err)
+	}
+
+	_, err = r.ProcessJSON(`
+{"Name": "Convolution", "Options": {"Kernel": {"Size": 3}}}
+{"Name": "Convolution", "Options": {
+	"Kernel": {"Values": [1]},
+	"Channels": [{"Name": "r"}, {}],
+	"Extra": {"blur": {"Size": 1, "Sise": 2}}
+}}`, nil)
+	if !errors.Is(err, graph.ErrMissingOption) || !errors.Is(err, graph.ErrUnknownOption) {
+		t.Fatalf("Expected %v and %v, got %v\n", graph.ErrMissingOption, graph.ErrUnknownOption, err)
+	}
+
+	for _, path := range []string{"/1/options/Kernel/Size", "/1/options/Channels/1/Name", "/1/options/Extra/blur/Sise"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Fatalf("Expected %s in %v\n", path, err)
+		}
+	}
+
+	if _, err := r.ProcessJSON(`{"Name": "Convolution"}`, nil); !errors.Is(err, graph.ErrMissingOption) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrMissingOption, err)
+	}
+
+	err = r.Register("Invalid", func(opts json.RawMessage) (graph.Linker, error) {
+		return base.NewLinker(), nil
+	}, graph.WithOptions(""))
+	if err != graph.ErrInvalidOptionType {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrInvalidOptionType, err)
+	}
+}
+
+func TestRegistrySignature(t *testing.T) {
+	r := graph.NewRegistry()
+
+	err := r.Register("Load", func(opts json.RawMessage) (graph.Linker, error) {
+		l := base.NewLinker()
+		ref := base.NewOutputConnector("ref")
+		l.OutputConnectors[ref.Name()] = ref
+
+		return l, nil
+	}, graph.WithInputs(graph.InputName), graph.WithOutputs(graph.OutputName, "ref"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	err = r.Register("Save", func(opts json.RawMessage) (graph.Linker, error) {
+		l := base.NewLinker()
+		dup := base.NewInputConnector("dup")
+		l.InputConnectors[dup.Name()] = dup
+
+		return l, nil
+	}, graph.WithInputs(graph.InputName, "dup"), graph.WithOutputs(graph.OutputName))
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	err = r.Register("Pass", func(opts json.RawMessage) (graph.Linker, error) {
+		return base.NewLinker(), nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	sig, ok := r.Signature("Load")
+	if !ok || len(sig.Inputs) != 1 || len(sig.Outputs) != 2 || sig.Outputs[1] != "ref" {
+		t.Fatalf("Unexpected signature %v\n", sig)
+	}
+
+	if _, ok := r.Signature("Pass"); ok {
+		t.Fatalf("Pass shouldn't have a signature\n")
+	}
+
+	if _, err := r.ProcessJSON(`{"Name": "Load", "Outputs": {"ref": {"Name": "Pass"}}}`, nil); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for _, c := range []struct {
+		data string
+		path string
+	}{
+		{`{"Name": "Load", "Outputs": {"bogus": {"Name": "Pass"}}}`, "/0/outputs/bogus"},
+		{`{"Name": "Load", "Outputs": {"Output": {"Name": "Save", "Input": "dupe"}}}`, "/0/outputs/Output/input"},
+		{`{"Name": "Pass", "Outputs": {"Output": {"Name": "Pass", "Input": "aux"}}}`, "/0/outputs/Output"},
+		{`{"Name": "Pass", "Outputs": {"aux": {"Name": "Pass"}}}`, "/0/outputs/aux"},
+		{`{"nodes": [{"id": "a", "name": "Load"}, {"id": "b", "name": "Save"}],
+			"edges": [{"from": "a", "to": "b", "toConnector": "aux"}]}`, "/0/edges/0/toConnector"},
+	} {
+		_, err := r.ProcessJSON(c.data, nil)
+		if !errors.Is(err, graph.ErrUnknownConnector) {
+			t.Fatalf("Expected %v, got %v\n", graph.ErrUnknownConnector, err)
+		}
+
+		if !strings.Contains(err.Error(), c.path) {
+			t.Fatalf("Expected %s in %v\n", c.path, err)
+		}
+	}
+
+	err = r.Register("Broken", func(opts json.RawMessage) (graph.Linker, error) {
+		return base.NewLinker(), nil
+	}, graph.WithInputs("aux"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if _, err := r.ProcessJSON(`{"Name": "Broken"}`, nil); err == nil {
+		t.Fatalf("Expected an error for a linker that doesn't match its signature\n")
+	}
+}
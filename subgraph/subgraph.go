@@ -0,0 +1,250 @@
+// Package subgraph allows registering a whole graph document as a reusable
+// linker. The connectors of a subgraph linker are mapped to connectors of the
+// linkers of its inner graph, which are identified by their reference ids.
+//
+// A subgraph may either be expanded, in which case graph.ProcessJSON replaces
+// it with the linkers of its inner graph, or it may be walked as a single
+// node, which processes its inner graph using a graph.Executor. This is
+// selected by the "Expand" option of every use of the subgraph:
+//
+//	{
+//		"Name": "EdgeDetect",
+//		"Options": {"Expand": true, "Vars": {"threshold": 0.5}},
+//		"Outputs": {
+//			"Output": {"Name": "Save", "Options": {"Path": "/tmp/out.png"}}
+//		}
+//	}
+//
+// A subgraph that contains itself, either directly or through other
+// subgraphs, cannot be constructed, and is reported with an error wrapping
+// ErrCycle.
+package subgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+var ErrCycle = errors.New("The subgraph contains itself")
+
+// buildingKey is the context key of the names of the subgraphs whose
+// documents are being processed, in order
+type buildingKey struct{}
+
+// Port maps a connector of a subgraph linker to a connector of one of the
+// linkers of its inner graph
+type Port struct {
+	// Name is the name of the subgraph linker's connector
+	Name graph.ConnectorName
+	// Reference is the reference id of the inner linker
+	Reference string
+	// Connector is the name of the inner linker's connector. If empty, the
+	// default name is used
+	Connector graph.ConnectorName
+}
+
+// Options are the options of every use of a subgraph within a document
+type Options struct {
+	// Expand replaces the subgraph linker with the linkers of its inner
+	// graph. Otherwise, the subgraph is walked as a single node
+	Expand bool
+	// Vars are the variables used when parsing the inner graph document,
+	// which is always parsed using text/template
+	Vars map[string]interface{}
+}
+
+// Linker is the linker of a single use of a subgraph. Its node processes the
+// inner graph, feeding it the values of the linker's input connectors, and
+// emitting the values of its output connectors
+type Linker struct {
+	*base.Linker
+
+	name    string
+	options json.RawMessage
+	expand  bool
+	roots   []graph.Linker
+	ports   map[graph.ConnectorType]map[graph.ConnectorName]graph.Endpoint
+}
+
+type node struct {
+	graph.Node
+
+	linker *Linker
+}
+
+// Register registers the graph document under the given name in the
+// registry, exposing the given ports as the input and output connectors of
+// its linkers. The document is read as described by graph.ProcessJSON, using
+// the same registry, every time the subgraph is used
+func Register(reg *graph.Registry, name string, document string, inputs, outputs []Port) error {
+	return reg.RegisterContext(name, func(ctx context.Context, opts json.RawMessage) (graph.Linker, error) {
+		return newLinker(ctx, reg, name, document, opts, inputs, outputs)
+	},
+		graph.WithOptions(Options{}),
+		graph.WithInputs(portNames(inputs)...),
+		graph.WithOutputs(portNames(outputs)...),
+	)
+}
+
+func newLinker(ctx context.Context, reg *graph.Registry, name, document string, opts json.RawMessage, inputs, outputs []Port) (*Linker, error) {
+	building, _ := ctx.Value(buildingKey{}).([]string)
+	for _, b := range building {
+		if b == name {
+			return nil, fmt.Errorf("%s -> %s: %w", strings.Join(building, " -> "), name, ErrCycle)
+		}
+	}
+
+	building = append(building[:len(building):len(building)], name)
+	ctx = context.WithValue(ctx, buildingKey{}, building)
+
+	var o Options
+	if len(opts) > 0 {
+		if err := json.Unmarshal(opts, &o); err != nil {
+			return nil, fmt.Errorf("decoding subgraph %s options: %v", name, err)
+		}
+	}
+
+	roots, refs, err := reg.ProcessJSONReferencesContext(ctx, document, &graph.JSONTemplateData{Vars: o.Vars})
+	if err != nil {
+		return nil, fmt.Errorf("processing subgraph %s: %w", name, err)
+	}
+
+	l := &Linker{name: name, options: opts, expand: o.Expand, roots: roots,
+		ports: map[graph.ConnectorType]map[graph.ConnectorName]graph.Endpoint{
+			graph.InputType:  make(map[graph.ConnectorName]graph.Endpoint),
+			graph.OutputType: make(map[graph.ConnectorName]graph.Endpoint),
+		}}
+
+	l.Linker = base.NewLinkerNode(node{Node: base.NewNode(), linker: l})
+	l.Linker.InputConnectors = make(map[graph.ConnectorName]graph.Connector)
+	l.Linker.OutputConnectors = make(map[graph.ConnectorName]graph.Connector)
+
+	for _, p := range inputs {
+		if err := l.addPort(p, graph.InputType, refs); err != nil {
+			return nil, fmt.Errorf("subgraph %s: %w", name, err)
+		}
+		l.Linker.InputConnectors[p.Name] = base.NewInputConnector(p.Name)
+	}
+
+	for _, p := range outputs {
+		if err := l.addPort(p, graph.OutputType, refs); err != nil {
+			return nil, fmt.Errorf("subgraph %s: %w", name, err)
+		}
+		l.Linker.OutputConnectors[p.Name] = base.NewOutputConnector(p.Name)
+	}
+
+	return l, nil
+}
+
+func (l *Linker) addPort(p Port, kind graph.ConnectorType, refs map[string]graph.Linker) error {
+	inner, ok := refs[p.Reference]
+	if !ok {
+		return fmt.Errorf("port %s: %s: %w", p.Name, p.Reference, graph.ErrUnresolvedReference)
+	}
+
+	name := p.Connector
+	if name == "" {
+		name = graph.InputName
+		if kind == graph.OutputType {
+			name = graph.OutputName
+		}
+	}
+
+	c := inner.Connector(name, kind)
+	if c == nil {
+		return fmt.Errorf("port %s: %s: %w", p.Name, name, graph.ErrUnknownConnector)
+	}
+
+	l.ports[kind][p.Name] = graph.Endpoint{Linker: inner, Connector: c}
+
+	return nil
+}
+
+// Expanded reports whether the subgraph is replaced by its inner graph
+func (l *Linker) Expanded() bool {
+	return l.expand
+}
+
+// Port returns the inner linker and connector that are mapped to the
+// subgraph's connector with the given name and type
+func (l *Linker) Port(name graph.ConnectorName, kind graph.ConnectorType) (graph.Endpoint, bool) {
+	ep, ok := l.ports[kind][name]
+	return ep, ok
+}
+
+// Roots returns the roots of the inner graph
+func (l *Linker) Roots() []graph.Linker {
+	return l.roots
+}
+
+// MarshalLinkerJSON returns the name under which the subgraph is registered,
+// and the options of its use
+func (l *Linker) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	return l.name, l.options, nil
+}
+
+// Process walks the inner graph using an executor. The values of the
+// subgraph's input connectors are fed to the mapped inner connectors, and the
+// values emitted by the mapped inner output connectors are emitted by the
+// subgraph
+func (n node) Process(ctx context.Context, wd graph.WalkData) error {
+	// A single walker covers all parts of the inner graph, since a root may
+	// feed another one through a reference
+	w, err := graph.NewMultiWalker(n.linker.roots...)
+	if err != nil {
+		return fmt.Errorf("subgraph %s: %w", n.linker.name, err)
+	}
+
+	for name, ep := range n.linker.ports[graph.InputType] {
+		l, c := resolve(ep.Linker, ep.Connector.Name(), graph.InputType)
+		if v, ok := wd.Input(name); ok {
+			w.SetInput(l.Node(), c, v)
+		}
+	}
+
+	if err := graph.NewExecutor(0).Run(ctx, w); err != nil {
+		return fmt.Errorf("subgraph %s: %w", n.linker.name, err)
+	}
+
+	for name, ep := range n.linker.ports[graph.OutputType] {
+		l, c := resolve(ep.Linker, ep.Connector.Name(), graph.OutputType)
+		if v, ok := w.Output(l.Node(), c); ok {
+			wd.Emit(name, v)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the linker and connector that replace the connector of an
+// inner subgraph, if it is expanded
+func resolve(l graph.Linker, name graph.ConnectorName, kind graph.ConnectorType) (graph.Linker, graph.ConnectorName) {
+	for {
+		el, ok := l.(graph.ExpandingLinker)
+		if !ok || !el.Expanded() {
+			return l, name
+		}
+
+		ep, ok := el.Port(name, kind)
+		if !ok {
+			return l, name
+		}
+
+		l, name = ep.Linker, ep.Connector.Name()
+	}
+}
+
+func portNames(ports []Port) []graph.ConnectorName {
+	names := make([]graph.ConnectorName, len(ports))
+	for i, p := range ports {
+		names[i] = p.Name
+	}
+
+	return names
+}
@@ -0,0 +1,254 @@
+package subgraph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+	"github.com/urandom/graph/subgraph"
+)
+
+type constNode struct {
+	graph.Node
+
+	value int
+}
+
+type mulNode struct {
+	graph.Node
+
+	by int
+}
+
+type collectNode struct {
+	graph.Node
+
+	result *int
+}
+
+func (n constNode) Process(ctx context.Context, wd graph.WalkData) error {
+	wd.Emit(graph.OutputName, n.value)
+	return nil
+}
+
+func (n mulNode) Process(ctx context.Context, wd graph.WalkData) error {
+	v, err := graph.InputAs[int](wd, graph.InputName)
+	if err != nil {
+		return err
+	}
+
+	wd.Emit(graph.OutputName, v*n.by)
+	return nil
+}
+
+func (n collectNode) Process(ctx context.Context, wd graph.WalkData) error {
+	v, err := graph.InputAs[int](wd, graph.InputName)
+	if err != nil {
+		return err
+	}
+
+	*n.result = v
+	return nil
+}
+
+func newRegistry(t *testing.T, result *int) *graph.Registry {
+	r := graph.NewRegistry()
+
+	constructors := map[string]func(v int) graph.Node{
+		"Const":   func(v int) graph.Node { return constNode{Node: base.NewNode(), value: v} },
+		"Mul":     func(v int) graph.Node { return mulNode{Node: base.NewNode(), by: v} },
+		"Collect": func(v int) graph.Node { return collectNode{Node: base.NewNode(), result: result} },
+	}
+
+	for name, c := range constructors {
+		c := c
+		err := r.Register(name, func(opts json.RawMessage) (graph.Linker, error) {
+			var o struct{ Value int }
+			if len(opts) > 0 {
+				if err := json.Unmarshal(opts, &o); err != nil {
+					return nil, err
+				}
+			}
+
+			return base.NewLinkerNode(c(o.Value)), nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+	}
+
+	err := subgraph.Register(r, "Quad", testQuad,
+		[]subgraph.Port{{Name: graph.InputName, Reference: "in"}},
+		[]subgraph.Port{{Name: graph.OutputName, Reference: "out"}},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	return r
+}
+
+func TestSubgraph(t *testing.T) {
+	cases := []struct {
+		expand   bool
+		vars     string
+		total    int
+		expected int
+	}{
+		{expand: true, vars: "{}", total: 4, expected: 12},
+		{expand: false, vars: "{}", total: 3, expected: 12},
+		{expand: true, vars: `{"by": 3}`, total: 4, expected: 18},
+		{expand: false, vars: `{"by": 3}`, total: 3, expected: 18},
+	}
+
+	for _, c := range cases {
+		var result int
+		r := newRegistry(t, &result)
+
+		expand, _ := json.Marshal(c.expand)
+		roots, err := r.ProcessJSON(`
+{
+	"Name": "Const",
+	"Options": {"Value": 3},
+	"Outputs": {
+		"Output": {
+			"Name": "Quad",
+			"Options": {"Expand": `+string(expand)+`, "Vars": `+c.vars+`},
+			"Outputs": {
+				"Output": {"Name": "Collect"}
+			}
+		}
+	}
+}`, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		w, err := graph.NewWalker(roots[0])
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if w.Total() != c.total {
+			t.Fatalf("Expected %v, got %v\n", c.total, w.Total())
+		}
+
+		if err := graph.NewExecutor(0).Run(context.Background(), w); err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if result != c.expected {
+			t.Fatalf("Expected %v, got %v\n", c.expected, result)
+		}
+	}
+}
+
+func TestSubgraphRoot(t *testing.T) {
+	var result int
+	r := newRegistry(t, &result)
+
+	roots, err := r.ProcessJSON(`{"Name": "Quad", "Options": {"Expand": true}}`, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if _, ok := roots[0].Node().(mulNode); !ok || len(roots) != 1 {
+		t.Fatalf("Expected the inner root, got %v\n", roots)
+	}
+
+	err = subgraph.Register(r, "Invalid", testQuad,
+		[]subgraph.Port{{Name: graph.InputName, Reference: "missing"}}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if _, err := r.ProcessJSON(`{"Name": "Invalid"}`, nil); !errors.Is(err, graph.ErrUnresolvedReference) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrUnresolvedReference, err)
+	}
+}
+
+func TestSubgraphForwardReference(t *testing.T) {
+	var result int
+	r := newRegistry(t, &result)
+
+	err := subgraph.Register(r, "ConstQuad", `
+{
+	"Name": "Mul",
+	"Options": {"Value": 2},
+	"ReferenceId": "in",
+	"Outputs": {
+		"Output": {"Name": "Mul", "Options": {"Value": 2}, "ReferenceId": "out"}
+	}
+}
+{"Name": "Const", "Options": {"Value": 3}, "Outputs": {"Output": {"ReferenceTo": "in"}}}`,
+		nil, []subgraph.Port{{Name: graph.OutputName, Reference: "out"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	roots, err := r.ProcessJSON(`{"Name": "ConstQuad", "Outputs": {"Output": {"Name": "Collect"}}}`, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	w, err := graph.NewWalker(roots[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if err := graph.NewExecutor(0).Run(context.Background(), w); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := 12
+	if result != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, result)
+	}
+}
+
+func TestSubgraphCycle(t *testing.T) {
+	var result int
+	r := newRegistry(t, &result)
+
+	documents := map[string]string{
+		"Loop": `{"Name": "Loop"}`,
+		"A":    `{"Name": "Const", "Options": {"Value": 1}, "Outputs": {"Output": {"Name": "B"}}}`,
+		"B":    `{"Name": "A"}`,
+	}
+
+	for name, document := range documents {
+		if err := subgraph.Register(r, name, document, []subgraph.Port{}, nil); err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+	}
+
+	for _, name := range []string{"Loop", "A", "B"} {
+		if _, err := r.ProcessJSON(`{"Name": "`+name+`"}`, nil); !errors.Is(err, subgraph.ErrCycle) {
+			t.Fatalf("Expected %v, got %v\n", subgraph.ErrCycle, err)
+		}
+	}
+
+	// The same subgraph may still be used more than once, as long as it
+	// does not contain itself
+	roots, err := r.ProcessJSON(`
+{
+	"Name": "Quad",
+	"Outputs": {"Output": {"Name": "Quad", "Outputs": {"Output": {"Name": "Collect"}}}}
+}`, nil)
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+}
+
+const testQuad = `
+{
+	"Name": "Mul",
+	"Options": {"Value": {{ var "by" | default 2 | json }}},
+	"ReferenceId": "in",
+	"Outputs": {
+		"Output": {"Name": "Mul", "Options": {"Value": 2}, "ReferenceId": "out"}
+	}
+}`
@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateFuncs returns the functions that are available to templates
+// processed with the given data, as described by ProcessJSON
+func templateFuncs(data *JSONTemplateData) template.FuncMap {
+	return template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"env": os.Getenv,
+		"var": func(name string) interface{} {
+			return data.Vars[name]
+		},
+		"required": func(name string) (interface{}, error) {
+			v, ok := data.Vars[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", name, ErrMissingParameter)
+			}
+
+			return v, nil
+		},
+		"default": func(def, v interface{}) interface{} {
+			if isEmpty(v) {
+				return def
+			}
+
+			return v
+		},
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+
+	return rv.IsZero()
+}
+
+// missingParameters returns an error for each parameter that is declared as
+// required by the template, but is not defined in the data. Only parameters
+// whose name is a constant string are declared
+func missingParameters(t *template.Template, data *JSONTemplateData) (errs DocumentErrors) {
+	seen := make(map[string]bool)
+
+	var visit func(n parse.Node)
+	visit = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, c := range n.Nodes {
+					visit(c)
+				}
+			}
+		case *parse.ActionNode:
+			visit(n.Pipe)
+		case *parse.IfNode:
+			visit(&n.BranchNode)
+		case *parse.RangeNode:
+			visit(&n.BranchNode)
+		case *parse.WithNode:
+			visit(&n.BranchNode)
+		case *parse.BranchNode:
+			visit(n.Pipe)
+			visit(n.List)
+			visit(n.ElseList)
+		case *parse.TemplateNode:
+			visit(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, c := range n.Cmds {
+					visit(c)
+				}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) > 1 {
+				ident, ok := n.Args[0].(*parse.IdentifierNode)
+				name, isString := n.Args[1].(*parse.StringNode)
+				if ok && isString && ident.Ident == "required" && !seen[name.Text] {
+					seen[name.Text] = true
+
+					if _, ok := data.Vars[name.Text]; !ok {
+						errs = append(errs, &DocumentError{
+							Offset: int64(n.Position()),
+							Err:    fmt.Errorf("%s: %w", name.Text, ErrMissingParameter),
+						})
+					}
+				}
+			}
+
+			for _, a := range n.Args {
+				visit(a)
+			}
+		}
+	}
+
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			visit(tmpl.Tree.Root)
+		}
+	}
+
+	return errs
+}
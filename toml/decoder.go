@@ -0,0 +1,83 @@
+// Package toml reads graph documents written in TOML. A document describes a
+// single graph root, in the same way as the json roots read by
+// graph.ProcessJSON. Several roots may be described by an array of tables
+// named "roots", or by using the flat format of nodes and edges.
+//
+//	# The edge detection pipeline
+//	name = "Load"
+//
+//	[options]
+//	path = "/tmp/in.png"
+//
+//	[outputs.Output]
+//	name = "Save"
+//	options = { path = "/tmp/out.png" }
+//
+// The decoder is used with graph.ProcessDocument, or with the ProcessDocument
+// method of a graph.Registry. Since TOML does not keep track of the position
+// of values, only parse errors report a line and column.
+package toml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urandom/graph"
+)
+
+// Decoder reads the roots of a TOML graph document
+type Decoder struct {
+	r     io.Reader
+	roots []interface{}
+	read  bool
+}
+
+// NewDecoder creates a decoder that reads from the given reader
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode returns the next root of the document, or io.EOF if there are no
+// more roots. The whole input is parsed on the first call
+func (d *Decoder) Decode() (graph.DocumentRoot, error) {
+	if !d.read {
+		d.read = true
+
+		var doc map[string]interface{}
+		if _, err := toml.NewDecoder(d.r).Decode(&doc); err != nil {
+			derr := &graph.DocumentError{Err: fmt.Errorf("decoding root: %w", err)}
+
+			var perr toml.ParseError
+			if errors.As(err, &perr) {
+				derr.Line, derr.Column = perr.Position.Line, perr.Position.Col
+			}
+
+			return graph.DocumentRoot{}, derr
+		}
+
+		if roots, ok := doc["roots"].([]map[string]interface{}); ok && len(doc) == 1 {
+			for _, r := range roots {
+				d.roots = append(d.roots, r)
+			}
+		} else if len(doc) > 0 {
+			d.roots = append(d.roots, doc)
+		}
+	}
+
+	if len(d.roots) == 0 {
+		return graph.DocumentRoot{}, io.EOF
+	}
+
+	root := d.roots[0]
+	d.roots = d.roots[1:]
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return graph.DocumentRoot{}, fmt.Errorf("converting root: %v", err)
+	}
+
+	return graph.DocumentRoot{JSON: data}, nil
+}
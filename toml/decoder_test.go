@@ -0,0 +1,167 @@
+package toml_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+	"github.com/urandom/graph/toml"
+)
+
+type namedNode struct {
+	graph.Node
+
+	name string
+	opts json.RawMessage
+}
+
+func (n namedNode) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	return n.name, n.opts, nil
+}
+
+func newRegistry(t *testing.T) *graph.Registry {
+	r := graph.NewRegistry()
+
+	for _, name := range []string{"Load", "Save"} {
+		name := name
+		err := r.Register(name, func(opts json.RawMessage) (graph.Linker, error) {
+			l := base.NewLinkerNode(namedNode{Node: base.NewNode(), name: name, opts: opts})
+			l.InputConnectors["Aux"] = base.NewInputConnector("Aux")
+
+			return l, nil
+		}, graph.WithInputs(graph.InputName, "Aux"), graph.WithOutputs(graph.OutputName))
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+	}
+
+	return r
+}
+
+func TestDecoder(t *testing.T) {
+	r := newRegistry(t)
+
+	expected, err := r.ProcessJSON(testJSON, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	for _, input := range []string{testTOML, testTOMLFlat} {
+		roots, err := r.ProcessDocument(toml.NewDecoder(strings.NewReader(input)))
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		e, err := graph.MarshalJSON(expected)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		a, err := graph.MarshalJSON(roots)
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+
+		if string(e) != string(a) {
+			t.Fatalf("Expected %s, got %s\n", e, a)
+		}
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	r := newRegistry(t)
+
+	_, err := r.ProcessDocument(toml.NewDecoder(strings.NewReader(testTOMLInvalid)))
+	if !errors.Is(err, graph.ErrUnknownConnector) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrUnknownConnector, err)
+	}
+
+	var derr *graph.DocumentError
+	_, err = r.ProcessDocument(toml.NewDecoder(strings.NewReader("name = \"Load\"\noptions = [\n")))
+	if !errors.As(err, &derr) || derr.Path != "/0" || derr.Line != 2 {
+		t.Fatalf("Expected a document error at line 2, got %v\n", err)
+	}
+}
+
+const (
+	testJSON = `
+{
+	"name": "Load",
+	"options": {"path": "in.png", "quality": 90},
+	"outputs": {
+		"Output": [
+			{"name": "Save", "options": {"path": "out.png"}, "referenceId": "save"},
+			{"name": "Save", "options": {"path": "out.jpg", "quality": 50}}
+		]
+	}
+}
+{
+	"name": "Load",
+	"options": {"path": "other.png", "quality": 90},
+	"outputs": {"Output": {"referenceTo": "save", "input": "Aux"}}
+}`
+	testTOML = `
+# The first branch
+[[roots]]
+name = "Load"
+options = { path = "in.png", quality = 90 }
+
+[[roots.outputs.Output]]
+name = "Save"
+options = { path = "out.png" }
+referenceId = "save"
+
+[[roots.outputs.Output]]
+name = "Save"
+options = { path = "out.jpg", quality = 50 }
+
+# The second branch
+[[roots]]
+name = "Load"
+options = { path = "other.png", quality = 90 }
+outputs.Output = { referenceTo = "save", input = "Aux" }
+`
+	testTOMLFlat = `
+[[nodes]]
+id = "in"
+name = "Load"
+options = { path = "in.png", quality = 90 }
+
+[[nodes]]
+id = "other"
+name = "Load"
+options = { path = "other.png", quality = 90 }
+
+[[nodes]]
+id = "png"
+name = "Save"
+options = { path = "out.png" }
+
+[[nodes]]
+id = "jpg"
+name = "Save"
+options = { path = "out.jpg", quality = 50 }
+
+[[edges]]
+from = "in"
+to = "png"
+
+[[edges]]
+from = "in"
+to = "jpg"
+
+[[edges]]
+from = "other"
+to = "png"
+toConnector = "Aux"
+`
+	testTOMLInvalid = `
+name = "Load"
+
+[outputs.Mask]
+name = "Save"
+`
+)
@@ -0,0 +1,61 @@
+package graph
+
+import "sort"
+
+// TopologicalSort returns the nodes of the graph that would be walked from the
+// given starting linker, ordered so that each node comes after all of its
+// parents. Nodes that do not depend on each other are ordered by their ids, so
+// the result is the same for the same graph. An error is returned if the graph
+// is not valid, as described by Validate
+func TopologicalSort(start Linker) ([]Node, error) {
+	linkers := findLinkers(start)
+	if err := validate(linkers); err != nil {
+		return nil, err
+	}
+
+	return sortLinkers(linkers), nil
+}
+
+func sortLinkers(linkers []Linker) []Node {
+	roots, count, children, parents := findRoots(linkers)
+
+	pending := make(map[Id]int, len(parents))
+	for id, c := range parents {
+		pending[id] = c
+	}
+
+	ready := make([]Linker, len(roots))
+	copy(ready, roots)
+	sortById(ready)
+
+	nodes := make([]Node, 0, count)
+	for len(ready) > 0 {
+		l := ready[0]
+		ready = ready[1:]
+
+		nodes = append(nodes, l.Node())
+
+		for _, c := range children[l.Node().Id()] {
+			cid := c.Node().Id()
+
+			pending[cid]--
+			if pending[cid] == 0 {
+				i := sort.Search(len(ready), func(i int) bool {
+					return ready[i].Node().Id() > cid
+				})
+
+				ready = append(ready, nil)
+				copy(ready[i+1:], ready[i:])
+				ready[i] = c
+			}
+		}
+	}
+
+	return nodes
+}
+
+func sortById(linkers []Linker) {
+	sort.Slice(linkers, func(i, j int) bool {
+		return linkers[i].Node().Id() < linkers[j].Node().Id()
+	})
+}
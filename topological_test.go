@@ -0,0 +1,94 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestTopologicalSort(t *testing.T) {
+	linkers := setupGraph()
+
+	nodes, err := graph.TopologicalSort(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if len(nodes) != len(linkers) {
+		t.Fatalf("Expected %v, got %v\n", len(linkers), len(nodes))
+	}
+
+	positions := map[graph.Id]int{}
+	for i, n := range nodes {
+		positions[n.Id()] = i
+	}
+
+	for _, l := range linkers {
+		for _, p := range graph.NewWalkData(l.Node(), l.Connectors(), nil).Parents {
+			if positions[p.Node.Id()] > positions[l.Node().Id()] {
+				t.Fatalf("Node %v should be sorted before %v\n", p.Node, l.Node())
+			}
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		again, _ := graph.TopologicalSort(linkers[0])
+		for j := range nodes {
+			if nodes[j].Id() != again[j].Id() {
+				t.Fatalf("Expected %v, got %v\n", nodes[j], again[j])
+			}
+		}
+	}
+}
+
+func TestTopologicalSortTieBreak(t *testing.T) {
+	root := base.NewLinker()
+
+	var children []*base.Linker
+	for i := 0; i < 5; i++ {
+		children = append(children, base.NewLinker())
+	}
+
+	for i := len(children) - 1; i >= 0; i-- {
+		c := base.NewOutputConnector(graph.ConnectorName(rune('a' + i)))
+		root.OutputConnectors[c.Name()] = c
+		root.Connect(children[i], c, children[i].Connector(graph.InputName))
+	}
+
+	nodes, err := graph.TopologicalSort(root)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if nodes[0].Id() != root.Node().Id() {
+		t.Fatalf("Expected %v, got %v\n", root.Node(), nodes[0])
+	}
+
+	for i, c := range children {
+		if nodes[i+1].Id() != c.Node().Id() {
+			t.Fatalf("Expected %v, got %v\n", c.Node(), nodes[i+1])
+		}
+	}
+
+	aux := base.NewInputConnector("aux")
+	children[1].InputConnectors[aux.Name()] = aux
+	children[2].Connect(children[1], children[2].Connector(graph.OutputName, graph.OutputType), aux)
+
+	nodes, err = graph.TopologicalSort(root)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if nodes[2].Id() != children[2].Node().Id() || nodes[3].Id() != children[1].Node().Id() {
+		t.Fatalf("Expected %v to be sorted before %v\n", children[2].Node(), children[1].Node())
+	}
+
+	children[1].Link(children[2])
+
+	var cerr *graph.CycleError
+	if _, err := graph.TopologicalSort(root); !errors.As(err, &cerr) {
+		t.Fatalf("Expected a cycle error, got %v\n", err)
+	}
+}
@@ -0,0 +1,219 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CycleError is reported when the nodes of a graph form a cycle
+type CycleError struct {
+	// Ids contains the ids of the nodes that form the cycle, in order
+	Ids []Id
+	// Connectors contains the names of the output connectors through which
+	// each node is connected to the next one, the last node being connected
+	// to the first one
+	Connectors []ConnectorName
+}
+
+// ConnectionError is reported when a connector is connected to a target that
+// is invalid, or that is not connected back to it
+type ConnectionError struct {
+	// Id is the id of the connector's node
+	Id Id
+	// Connector is the name of the connector
+	Connector ConnectorName
+	// Type is the type of the connector
+	Type ConnectorType
+	// Err is either ErrDanglingConnector or ErrHalfConnected
+	Err error
+}
+
+// Validate checks the graph that would be walked from the given starting
+// linker. It detects cycles, as well as connectors whose target is invalid or
+// does not point back to them, and different linkers whose nodes share the
+// same id. All problems are reported as a single joined error, made of
+// CycleErrors, ConnectionErrors and errors wrapping ErrDuplicateId
+func Validate(start Linker) error {
+	return validate(findLinkers(start))
+}
+
+func (e *CycleError) Error() string {
+	parts := make([]string, len(e.Ids))
+	for i, id := range e.Ids {
+		parts[i] = fmt.Sprintf("%d (%s)", id, e.Connectors[i])
+	}
+
+	return fmt.Sprintf("cycle detected: %s -> %d", strings.Join(parts, " -> "), e.Ids[0])
+}
+
+func (e *ConnectionError) Error() string {
+	kind := "input"
+	if e.Type == OutputType {
+		kind = "output"
+	}
+
+	return fmt.Sprintf("%s connector %s of node %d: %v", kind, e.Connector, e.Id, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+func validate(linkers []Linker) error {
+	var errs []error
+
+	for _, l := range linkers {
+		for _, kind := range []ConnectorType{InputType, OutputType} {
+			for _, c := range l.Connectors(kind) {
+				for _, t := range c.Targets() {
+					if err := validateTarget(l, c, t); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+		}
+	}
+
+	errs = append(errs, findDuplicates(linkers)...)
+	errs = append(errs, findCycles(linkers)...)
+
+	return errors.Join(errs...)
+}
+
+func validateTarget(l Linker, c Connector, t Endpoint) error {
+	cerr := &ConnectionError{Id: l.Node().Id(), Connector: c.Name(), Type: c.Type()}
+
+	if t.Linker == nil || t.Connector == nil ||
+		t.Linker.Connector(t.Connector.Name(), t.Connector.Type()) != t.Connector {
+		cerr.Err = ErrDanglingConnector
+		return cerr
+	}
+
+	for _, back := range t.Connector.Targets() {
+		if back.Connector == c && back.Linker != nil && back.Linker.Node().Id() == l.Node().Id() {
+			return nil
+		}
+	}
+
+	cerr.Err = ErrHalfConnected
+	return cerr
+}
+
+// findDuplicates reports the ids that are shared by different linkers. Since
+// linkers are tracked by the ids of their nodes, only the first linker with a
+// given id would be walked, and the rest would be silently skipped
+func findDuplicates(linkers []Linker) []error {
+	var errs []error
+
+	ids := make(map[Id]Linker, len(linkers))
+	for _, l := range linkers {
+		ids[l.Node().Id()] = l
+	}
+
+	reported := make(map[Id]bool)
+	for _, l := range linkers {
+		for _, kind := range []ConnectorType{InputType, OutputType} {
+			for _, c := range l.Connectors(kind) {
+				for _, t := range c.Targets() {
+					if t.Linker == nil || !ownsTarget(t.Linker, t) {
+						continue
+					}
+
+					id := t.Linker.Node().Id()
+					if known, ok := ids[id]; ok && !reported[id] && !ownsTarget(known, t) {
+						reported[id] = true
+						errs = append(errs, fmt.Errorf("node %d: %w", id, ErrDuplicateId))
+					}
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// ownsTarget reports whether the target's connector belongs to the linker.
+// Linkers that embed another one may point to the same connectors through
+// different values, which are still the same linker
+func ownsTarget(l Linker, t Endpoint) bool {
+	return t.Connector != nil && l.Connector(t.Connector.Name(), t.Connector.Type()) == t.Connector
+}
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// cycleFinder performs a depth-first traversal of the linkers, reporting a
+// cycle for each connection that leads back to a linker that is still being
+// traversed
+type cycleFinder struct {
+	members    *Visitor
+	state      map[Id]int
+	path       []Id
+	connectors []ConnectorName
+	errs       []error
+}
+
+func findCycles(linkers []Linker) []error {
+	f := cycleFinder{members: NewVisitor(), state: make(map[Id]int)}
+	for _, l := range linkers {
+		f.members.Add(l.Node())
+	}
+
+	for _, l := range linkers {
+		if f.state[l.Node().Id()] == unvisited {
+			f.visit(l)
+		}
+	}
+
+	return f.errs
+}
+
+func (f *cycleFinder) visit(l Linker) {
+	id := l.Node().Id()
+
+	f.state[id] = visiting
+	f.path = append(f.path, id)
+
+	for _, c := range l.Connectors(OutputType) {
+		f.connectors = append(f.connectors, c.Name())
+
+		for _, t := range c.Targets() {
+			if t.Linker == nil || !f.members.Visited(t.Linker.Node()) {
+				continue
+			}
+
+			tid := t.Linker.Node().Id()
+			switch f.state[tid] {
+			case unvisited:
+				f.visit(t.Linker)
+			case visiting:
+				f.errs = append(f.errs, f.cycle(tid))
+			}
+		}
+
+		f.connectors = f.connectors[:len(f.connectors)-1]
+	}
+
+	f.path = f.path[:len(f.path)-1]
+	f.state[id] = visited
+}
+
+func (f *cycleFinder) cycle(start Id) error {
+	i := len(f.path) - 1
+	for f.path[i] != start {
+		i--
+	}
+
+	cerr := &CycleError{
+		Ids:        make([]Id, len(f.path)-i),
+		Connectors: make([]ConnectorName, len(f.path)-i),
+	}
+	copy(cerr.Ids, f.path[i:])
+	copy(cerr.Connectors, f.connectors[i:])
+
+	return cerr
+}
@@ -0,0 +1,102 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+)
+
+func TestValidate(t *testing.T) {
+	linkers := setupGraph()
+
+	if err := graph.Validate(linkers[0]); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+}
+
+func TestValidateCycle(t *testing.T) {
+	linkers := make([]*base.Linker, 3)
+	for i := range linkers {
+		linkers[i] = base.NewLinker()
+		if i > 0 {
+			linkers[i-1].Link(linkers[i])
+		}
+	}
+
+	aux := base.NewInputConnector("aux")
+	linkers[1].InputConnectors[aux.Name()] = aux
+	linkers[2].Connect(linkers[1], linkers[2].Connector(graph.OutputName, graph.OutputType), aux)
+
+	err := graph.Validate(linkers[0])
+
+	var cerr *graph.CycleError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expected a cycle error, got %v\n", err)
+	}
+
+	expected := []graph.Id{linkers[1].Node().Id(), linkers[2].Node().Id()}
+	if len(cerr.Ids) != len(expected) || cerr.Ids[0] != expected[0] || cerr.Ids[1] != expected[1] {
+		t.Fatalf("Expected %v, got %v\n", expected, cerr.Ids)
+	}
+
+	for _, name := range cerr.Connectors {
+		if name != graph.OutputName {
+			t.Fatalf("Expected %v, got %v\n", graph.OutputName, name)
+		}
+	}
+
+	if _, err := graph.NewWalker(linkers[0]); !errors.As(err, &cerr) {
+		t.Fatalf("Expected a cycle error, got %v\n", err)
+	}
+}
+
+func TestValidateConnections(t *testing.T) {
+	l1, l2, l3 := base.NewLinker(), base.NewLinker(), base.NewLinker()
+
+	out := l1.Connector(graph.OutputName, graph.OutputType)
+	out.Connect(l2, l2.Connector(graph.InputName))
+
+	dup := base.NewOutputConnector("dup")
+	l1.OutputConnectors[dup.Name()] = dup
+	dup.Connect(l3, base.NewInputConnector())
+
+	err := graph.Validate(l1)
+	if !errors.Is(err, graph.ErrHalfConnected) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrHalfConnected, err)
+	}
+
+	if !errors.Is(err, graph.ErrDanglingConnector) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrDanglingConnector, err)
+	}
+
+	var cerr *graph.ConnectionError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expected a connection error, got %v\n", err)
+	}
+
+	if cerr.Id != l1.Node().Id() || cerr.Type != graph.OutputType {
+		t.Fatalf("Unexpected connection error %v\n", cerr)
+	}
+}
+
+func TestValidateDuplicateId(t *testing.T) {
+	l1, l2 := base.NewLinker(), base.NewLinker()
+	l3 := base.NewLinkerNode(l2.Node())
+
+	l1.Link(l2)
+
+	dup := base.NewOutputConnector("dup")
+	l1.OutputConnectors[dup.Name()] = dup
+	l1.Connect(l3, dup, l3.Connector(graph.InputName))
+
+	if _, err := graph.NewWalker(l1); !errors.Is(err, graph.ErrDuplicateId) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrDuplicateId, err)
+	}
+
+	l1.Disconnect(dup)
+	if _, err := graph.NewWalker(l1); err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+}
@@ -1,5 +1,10 @@
 package graph
 
+import (
+	"fmt"
+	"sync"
+)
+
 // WalkData represents the data that will be sent through the walk channel
 type WalkData struct {
 	// Node is the current node being visited
@@ -7,7 +12,9 @@ type WalkData struct {
 	// Parents contains the Parents of the node
 	Parents []Parent
 
-	done chan struct{}
+	done   chan struct{}
+	err    *error
+	values *walkValues
 }
 
 // Parent is a simple representation of the connection between the node and its
@@ -30,7 +37,47 @@ func NewWalkData(n Node, conns []Connector, d chan struct{}) WalkData {
 				Parent{From: o.Name(), To: c.Name(), Node: t.Node()})
 		}
 	}
-	return WalkData{Node: n, Parents: parents, done: d}
+	return WalkData{Node: n, Parents: parents, done: d, err: new(error),
+		values: newWalkValues()}
+}
+
+// Emit sets the value of the node's output connector with the given name. Once
+// the walk data is closed, the value is delivered to all input connectors
+// that are connected to that output connector
+func (wd WalkData) Emit(name ConnectorName, value interface{}) {
+	wd.values.set(wd.Node.Id(), name, value)
+}
+
+// Input returns the value that was emitted by the parent connected to the
+// node's input connector with the given name. If the connector has no parent
+// within the walked graph, the value set through the walker's SetInput is
+// returned instead. The boolean result is false if no value was emitted
+func (wd WalkData) Input(name ConnectorName) (interface{}, bool) {
+	for _, p := range wd.Parents {
+		if p.To == name && wd.values.member(p.Node) {
+			return wd.values.get(p.Node.Id(), p.From)
+		}
+	}
+
+	return wd.values.input(wd.Node.Id(), name)
+}
+
+// InputAs returns the value of the node's input connector with the given name
+// as the requested type. It returns ErrMissingInput if no value was emitted
+// for the connector, or an error if the value is of a different type
+func InputAs[T any](wd WalkData, name ConnectorName) (T, error) {
+	var t T
+
+	v, ok := wd.Input(name)
+	if !ok {
+		return t, fmt.Errorf("input %s: %w", name, ErrMissingInput)
+	}
+
+	if t, ok = v.(T); !ok {
+		return t, fmt.Errorf("input %s: expected a value of type %T, got %T", name, t, v)
+	}
+
+	return t, nil
 }
 
 // Close notifies the walker that any operation done using the information of
@@ -38,3 +85,68 @@ func NewWalkData(n Node, conns []Connector, d chan struct{}) WalkData {
 func (wd WalkData) Close() {
 	close(wd.done)
 }
+
+// Fail notifies the walker that the operation done using the information of
+// the node has failed. The walker will skip all of the node's descendants,
+// while still walking any independent branches. Once the walk ends, the error
+// is reported as part of a WalkError
+func (wd WalkData) Fail(err error) {
+	*wd.err = err
+	close(wd.done)
+}
+
+type walkValues struct {
+	sync.RWMutex
+	values map[Id]map[ConnectorName]interface{}
+	inputs map[Id]map[ConnectorName]interface{}
+	// members holds the nodes of the walked graph. If nil, every node is
+	// considered to be a part of it
+	members *Visitor
+}
+
+func newWalkValues() *walkValues {
+	return &walkValues{values: make(map[Id]map[ConnectorName]interface{}),
+		inputs: make(map[Id]map[ConnectorName]interface{})}
+}
+
+func (v *walkValues) setInput(id Id, name ConnectorName, value interface{}) {
+	defer v.Unlock()
+	v.Lock()
+
+	if v.inputs[id] == nil {
+		v.inputs[id] = make(map[ConnectorName]interface{})
+	}
+
+	v.inputs[id][name] = value
+}
+
+func (v *walkValues) input(id Id, name ConnectorName) (value interface{}, ok bool) {
+	defer v.RUnlock()
+	v.RLock()
+
+	value, ok = v.inputs[id][name]
+	return
+}
+
+func (v *walkValues) member(n Node) bool {
+	return v.members == nil || v.members.Visited(n)
+}
+
+func (v *walkValues) set(id Id, name ConnectorName, value interface{}) {
+	defer v.Unlock()
+	v.Lock()
+
+	if v.values[id] == nil {
+		v.values[id] = make(map[ConnectorName]interface{})
+	}
+
+	v.values[id][name] = value
+}
+
+func (v *walkValues) get(id Id, name ConnectorName) (value interface{}, ok bool) {
+	defer v.RUnlock()
+	v.RLock()
+
+	value, ok = v.values[id][name]
+	return
+}
@@ -1,18 +1,34 @@
 package graph
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
 
 // Walker helps traverse a graph
 type Walker struct {
-	start Linker
-	roots []Linker
-	wgm   map[Id]*sync.WaitGroup
-	count int
+	roots    []Linker
+	children map[Id][]Linker
+	parents  map[Id]int
+	members  *Visitor
+	count    int
+	io       *walkIO
 }
 
-type edge struct {
-	from Id
-	to   Id
+// walkIO holds the values that are fed into every walk of a walker, and the
+// values of its most recent walk
+type walkIO struct {
+	sync.Mutex
+	inputs map[Id]map[ConnectorName]interface{}
+	last   *walkValues
+}
+
+type walkResult struct {
+	id  Id
+	err error
 }
 
 // NewWalker creates a new walker with a given linker as a starting point of
@@ -20,31 +36,160 @@ type edge struct {
 // taken into account when counting and traversing the graph. It will
 // immediately find all other roots and count all nodes in the graph.
 //
-// A new walker has to be created if the structure of the graph changes
-func NewWalker(start Linker) Walker {
-	roots, count, wgm := findRoots(start)
+// A new walker has to be created if the structure of the graph changes. An
+// error is returned if the graph is not valid, as described by Validate
+func NewWalker(start Linker) (Walker, error) {
+	return newWalker(findLinkers(start))
+}
+
+// NewMultiWalker creates a new walker with several starting points, merging
+// the graphs that would be walked from each of them into a single walk. It
+// allows walking separate, disconnected components, such as the ones defined
+// by the roots of a single json document, with one channel and total. As
+// with NewWalker, the ancestors of a starting point are not taken into
+// account, unless they are reached from another starting point. An error
+// wrapping ErrDuplicateId is returned if different linkers of the merged
+// graphs share the same id.
+func NewMultiWalker(starts ...Linker) (Walker, error) {
+	var linkers []Linker
+
+	found := make(map[Id]Linker)
+	for _, start := range starts {
+		for _, l := range findLinkers(start) {
+			id := l.Node().Id()
+
+			if known, ok := found[id]; ok {
+				if !sameLinker(known, l) {
+					return Walker{}, fmt.Errorf("node %d: %w", id, ErrDuplicateId)
+				}
+
+				continue
+			}
 
-	w := Walker{start: start, roots: roots,
-		count: count, wgm: wgm}
+			found[id] = l
+			linkers = append(linkers, l)
+		}
+	}
 
-	return w
+	return newWalker(linkers)
+}
+
+// NewWalkerFor creates a new walker that only walks the given target and all
+// of its ancestors, which are the nodes whose results are needed to process
+// it. Descendants of the target, and any branches that do not lead to it, are
+// left out, and are not counted by Total.
+func NewWalkerFor(target Linker) (Walker, error) {
+	return newWalker(findAncestors(target))
+}
+
+// newWalker creates a walker over the given set of linkers. Connections to
+// linkers outside of the set are not followed
+func newWalker(linkers []Linker) (Walker, error) {
+	if err := validate(linkers); err != nil {
+		return Walker{}, err
+	}
+
+	roots, count, children, parents := findRoots(linkers)
+
+	members := NewVisitor()
+	for _, l := range linkers {
+		members.Add(l.Node())
+	}
+
+	w := Walker{roots: roots, members: members,
+		count: count, children: children, parents: parents,
+		io: &walkIO{inputs: make(map[Id]map[ConnectorName]interface{})}}
+
+	return w, nil
 }
 
 // Walk starts walking all roots simultaneously. It returns a channel of
 // WalkData, and each item of it has to be closed if the walker is to proceed
 // to the item's descendants.
 func (w Walker) Walk() <-chan WalkData {
+	nodes, _ := w.WalkContext(context.Background())
+
+	return nodes
+}
+
+// WalkContext is like Walk, but it stops walking when the context is cancelled
+// or its deadline passes. When that happens, all goroutines started by the
+// walk are torn down and the WalkData channel is closed, even if some of the
+// items that were already sent are never closed. The error channel receives a
+// single value once the WalkData channel is closed: nil if every node was
+// walked successfully, a *WalkError if any node has failed, or the context's
+// error if the walk was stopped.
+func (w Walker) WalkContext(ctx context.Context) (<-chan WalkData, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
 	nodes := make(chan WalkData)
-	counter := make(chan struct{})
-	v := NewVisitor()
+	counter := make(chan walkResult)
+	errc := make(chan error, 1)
+	values := newWalkValues()
+	values.members = w.members
+	if w.io != nil {
+		w.io.Lock()
+		for id, inputs := range w.io.inputs {
+			for name, value := range inputs {
+				values.setInput(id, name, value)
+			}
+		}
+		w.io.last = values
+		w.io.Unlock()
+	}
 
+	var senders sync.WaitGroup
 	for _, r := range w.roots {
-		go linkWalker(r, []Connector{}, nodes, counter, w.wgm, v)
+		senders.Add(1)
+		go linkWalker(ctx, r, nodes, counter, values, &senders)
 	}
 
-	go closeNodes(nodes, counter, w.Total())
+	go func() {
+		defer cancel()
 
-	return nodes
+		errc <- closeNodes(ctx, w, nodes, counter, values, &senders)
+		close(errc)
+	}()
+
+	return nodes, errc
+}
+
+// SetInput sets the value of the input connector of the given node, for every
+// subsequent walk. The value is only used if the connector is not connected to
+// a parent within the walked graph, which allows feeding values into the
+// graph from outside of it
+func (w Walker) SetInput(n Node, name ConnectorName, value interface{}) {
+	if w.io == nil {
+		return
+	}
+
+	defer w.io.Unlock()
+	w.io.Lock()
+
+	if w.io.inputs[n.Id()] == nil {
+		w.io.inputs[n.Id()] = make(map[ConnectorName]interface{})
+	}
+
+	w.io.inputs[n.Id()][name] = value
+}
+
+// Output returns the value that was emitted by the given node on its output
+// connector with the given name, during the most recent walk. The boolean
+// result is false if no value was emitted
+func (w Walker) Output(n Node, name ConnectorName) (interface{}, bool) {
+	if w.io == nil {
+		return nil, false
+	}
+
+	w.io.Lock()
+	values := w.io.last
+	w.io.Unlock()
+
+	if values == nil {
+		return nil, false
+	}
+
+	return values.get(n.Id(), name)
 }
 
 // Total returns the total number of nodes in the graph
@@ -62,157 +207,209 @@ func (w Walker) RootNodes() (roots []Node) {
 }
 
 func linkWalker(
+	ctx context.Context,
 	l Linker,
-	connectors []Connector,
 	nodes chan<- WalkData,
-	counter chan<- struct{},
-	wgm map[Id]*sync.WaitGroup,
-	v *Visitor,
+	counter chan<- walkResult,
+	values *walkValues,
+	senders *sync.WaitGroup,
 ) {
-	if !v.Add(l.Node()) {
+	done := make(chan struct{})
+	wd := NewWalkData(l.Node(), l.Connectors(), done)
+	wd.values = values
+
+	select {
+	case nodes <- wd:
+		senders.Done()
+	case <-ctx.Done():
+		senders.Done()
 		return
 	}
 
-	if wg, ok := wgm[l.Node().Id()]; ok {
-		wg.Wait()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return
 	}
 
-	done := make(chan struct{})
+	select {
+	case counter <- walkResult{id: l.Node().Id(), err: *wd.err}:
+	case <-ctx.Done():
+	}
+}
 
-	nodes <- NewWalkData(l.Node(), connectors, done)
+// closeNodes starts walking the children of each node once all of their
+// parents are done, and closes the nodes channel once every node has been
+// walked or skipped, or the context is done. It must not close the channel
+// while any linkWalker might still send on it.
+func closeNodes(
+	ctx context.Context,
+	w Walker,
+	nodes chan WalkData,
+	counter chan walkResult,
+	values *walkValues,
+	senders *sync.WaitGroup,
+) error {
+	defer func() {
+		senders.Wait()
+		close(nodes)
+	}()
 
-	for _, out := range l.Connectors(OutputType) {
-		if t, _ := out.Target(); t != nil {
-			go linkWalker(t, t.Connectors(), nodes, counter, wgm, v)
-		}
+	parents := make(map[Id]int, len(w.parents))
+	for id, count := range w.parents {
+		parents[id] = count
 	}
 
-	go func() {
+	errs := make(map[Id]error)
+	for total := w.count; total > 0; {
 		select {
-		case <-done:
-			for _, out := range l.Connectors(OutputType) {
-				if tl, _ := out.Target(); tl != nil {
-					if wg, ok := wgm[tl.Node().Id()]; ok {
-						wg.Done()
-					}
+		case r := <-counter:
+			total--
+
+			if r.err != nil {
+				errs[r.id] = r.err
+				total -= skipChildren(w, r.id, r.id, errs)
+			}
+
+			for _, c := range w.children[r.id] {
+				cid := c.Node().Id()
+
+				parents[cid]--
+				if _, skipped := errs[cid]; !skipped && parents[cid] == 0 {
+					senders.Add(1)
+					go linkWalker(ctx, c, nodes, counter, values, senders)
 				}
 			}
-			counter <- struct{}{}
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-	}()
+	}
+
+	if len(errs) > 0 {
+		return &WalkError{Errors: errs}
+	}
+
+	return nil
 }
 
-func closeNodes(nodes chan WalkData, counter <-chan struct{}, total int) {
-	for {
-		select {
-		case <-counter:
-			total--
-			if total == 0 {
-				close(nodes)
-			}
+// skipChildren marks all descendants of the given node as skipped, due to
+// the failure of the cause node. It returns the number of newly skipped nodes
+func skipChildren(w Walker, id, cause Id, errs map[Id]error) (count int) {
+	for _, c := range w.children[id] {
+		cid := c.Node().Id()
+		if _, ok := errs[cid]; ok {
+			continue
 		}
+
+		errs[cid] = fmt.Errorf("%w: ancestor %d has failed", ErrSkipped, cause)
+		count += 1 + skipChildren(w, cid, cause, errs)
 	}
-}
 
-func findRoots(l Linker) (roots []Linker, count int, wgm map[Id]*sync.WaitGroup) {
-	v := NewVisitor()
+	return
+}
 
-	wgm = make(map[Id]*sync.WaitGroup)
-	roots = append(roots, l)
-	count++
+// WalkError is reported by a walk in which at least one node has failed. It
+// holds the error of each failed node, as well as an error wrapping
+// ErrSkipped for each node that was skipped because of a failed ancestor
+type WalkError struct {
+	Errors map[Id]error
+}
 
-	v.Add(l.Node())
+func (e *WalkError) Error() string {
+	ids := make([]Id, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-	wgv := map[edge]bool{}
-	rr, rc := findBacktrackable(l, v, wgm, wgv)
-	roots = append(roots, rr...)
-	count += rc
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("node %d: %v", id, e.Errors[id])
+	}
 
-	return
+	return fmt.Sprintf("walking %d nodes has failed: %s", len(ids), strings.Join(msgs, "; "))
 }
 
-func findBacktrackable(
-	l Linker,
-	v *Visitor,
-	wgm map[Id]*sync.WaitGroup,
-	wgv map[edge]bool,
-) (roots []Linker, count int) {
-	for _, c := range l.Connectors(OutputType) {
-		if t, _ := c.Target(); t != nil {
-			if !v.Add(t.Node()) {
-				continue
-			}
-			count++
-
-			if len(t.Connectors()) > 1 {
-				rr, rc := findRootsBacktrack(t, v, wgm, wgv)
-
-				roots = append(roots, rr...)
-				count += rc
-			} else {
-				var wg sync.WaitGroup
-				hasParents := false
-				for _, in := range t.Connectors() {
-					if tc, _ := in.Target(); tc != nil {
-						edge := edge{from: tc.Node().Id(), to: t.Node().Id()}
-						if !wgv[edge] {
-							wg.Add(1)
-							hasParents = true
-							wgv[edge] = true
-						}
-					}
-				}
+// findRoots returns the linkers that have no parents among the given ones. For
+// each linker, it also returns its children and the number of connections from
+// its parents.
+func findRoots(linkers []Linker) (
+	roots []Linker,
+	count int,
+	children map[Id][]Linker,
+	parents map[Id]int,
+) {
+	v := NewVisitor()
+	for _, l := range linkers {
+		v.Add(l.Node())
+	}
 
-				if hasParents {
-					wgm[t.Node().Id()] = &wg
+	children = make(map[Id][]Linker)
+	parents = make(map[Id]int)
+	for _, l := range linkers {
+		for _, c := range l.Connectors(OutputType) {
+			for _, t := range c.Targets() {
+				if t.Linker != nil && v.Visited(t.Linker.Node()) {
+					children[l.Node().Id()] = append(children[l.Node().Id()], t.Linker)
+					parents[t.Linker.Node().Id()]++
 				}
-
 			}
+		}
+	}
 
-			rr, rc := findBacktrackable(t, v, wgm, wgv)
-			roots = append(roots, rr...)
-			count += rc
+	for _, l := range linkers {
+		if parents[l.Node().Id()] == 0 {
+			roots = append(roots, l)
 		}
 	}
 
-	return
+	return roots, len(linkers), children, parents
 }
 
-func findRootsBacktrack(
-	l Linker,
-	v *Visitor,
-	wgm map[Id]*sync.WaitGroup,
-	wgv map[edge]bool,
-) (roots []Linker, count int) {
-	var wg sync.WaitGroup
-	var hasParents bool
-
-	for _, in := range l.Connectors() {
-		if t, _ := in.Target(); t != nil {
-			edge := edge{from: t.Node().Id(), to: l.Node().Id()}
-			if !wgv[edge] {
-				wg.Add(1)
-				hasParents = true
-				wgv[edge] = true
+// findAncestors returns the target and all linkers connected, directly or
+// indirectly, to its input connectors
+func findAncestors(target Linker) []Linker {
+	v := NewVisitor()
+	v.Add(target.Node())
+
+	linkers := []Linker{target}
+	for i := 0; i < len(linkers); i++ {
+		for _, c := range linkers[i].Connectors(InputType) {
+			for _, t := range c.Targets() {
+				if t.Linker != nil && v.Add(t.Linker.Node()) {
+					linkers = append(linkers, t.Linker)
+				}
 			}
+		}
+	}
 
-			if !v.Add(t.Node()) {
-				continue
-			}
+	return linkers
+}
+
+// findLinkers returns the starting linker, all of its descendants, and
+// any other linker connected to them. The input connectors of the starting
+// linker are never followed.
+func findLinkers(start Linker) []Linker {
+	v := NewVisitor()
+	v.Add(start.Node())
+
+	linkers := []Linker{start}
+	for i := 0; i < len(linkers); i++ {
+		l := linkers[i]
 
-			count++
-			rr, rc := findRootsBacktrack(t, v, wgm, wgv)
-			roots = append(roots, rr...)
-			count += rc
+		connectors := l.Connectors(OutputType)
+		if i > 0 {
+			connectors = append(connectors, l.Connectors()...)
 		}
-	}
 
-	if count == 0 {
-		roots = append(roots, l)
-	}
-	if hasParents {
-		wgm[l.Node().Id()] = &wg
+		for _, c := range connectors {
+			for _, t := range c.Targets() {
+				if t.Linker != nil && v.Add(t.Linker.Node()) {
+					linkers = append(linkers, t.Linker)
+				}
+			}
+		}
 	}
 
-	return
+	return linkers
 }
@@ -1,6 +1,8 @@
 package graph_test
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
@@ -12,7 +14,10 @@ import (
 func TestWalker(t *testing.T) {
 	linkers := setupGraph()
 
-	w := graph.NewWalker(linkers[0])
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
 
 	expectedInt := 12
 	if w.Total() != expectedInt {
@@ -89,6 +94,265 @@ func TestWalker(t *testing.T) {
 	}
 }
 
+func TestWalkerContext(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	walker, errc := w.WalkContext(ctx)
+
+	count := 0
+	for wd := range walker {
+		count++
+
+		if count == 1 {
+			wd.Close()
+		} else {
+			cancel()
+		}
+	}
+
+	if count >= len(linkers) {
+		t.Fatalf("Expected less than %v nodes, got %v\n", len(linkers), count)
+	}
+
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("Expected %v, got %v\n", context.Canceled, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	walker, errc = w.WalkContext(ctx)
+
+	count = 0
+	for range walker {
+		count++
+	}
+
+	if count != len(w.RootNodes()) {
+		t.Fatalf("Expected %v, got %v\n", len(w.RootNodes()), count)
+	}
+
+	if err := <-errc; err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got %v\n", context.DeadlineExceeded, err)
+	}
+
+	walker, errc = w.WalkContext(context.Background())
+	for wd := range walker {
+		wd.Close()
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+}
+
+func TestWalkerFail(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	walker, errc := w.WalkContext(context.Background())
+
+	failure := errors.New("failure")
+	v := graph.NewVisitor()
+	for wd := range walker {
+		v.Add(wd.Node)
+
+		if wd.Node.Id() == linkers[2].Node().Id() {
+			wd.Fail(failure)
+		} else {
+			wd.Close()
+		}
+	}
+
+	for _, i := range []int{0, 1, 2, 3, 4, 5, 6, 10} {
+		if !v.Visited(linkers[i].Node()) {
+			t.Fatalf("Node %d should have been walked\n", i)
+		}
+	}
+
+	var werr *graph.WalkError
+	if err := <-errc; !errors.As(err, &werr) {
+		t.Fatalf("Expected a walk error, got %v\n", err)
+	}
+
+	expectedInt := 5
+	if len(werr.Errors) != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, len(werr.Errors))
+	}
+
+	if err := werr.Errors[linkers[2].Node().Id()]; err != failure {
+		t.Fatalf("Expected %v, got %v\n", failure, err)
+	}
+
+	for _, i := range []int{7, 8, 9, 11} {
+		if v.Visited(linkers[i].Node()) {
+			t.Fatalf("Node %d shouldn't have been walked\n", i)
+		}
+
+		if err := werr.Errors[linkers[i].Node().Id()]; !errors.Is(err, graph.ErrSkipped) {
+			t.Fatalf("Expected %v, got %v\n", graph.ErrSkipped, err)
+		}
+	}
+}
+
+func TestWalkerValues(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	walker, errc := w.WalkContext(context.Background())
+
+	for wd := range walker {
+		switch wd.Node.Id() {
+		case linkers[7].Node().Id():
+			wd.Emit(graph.OutputName, 7)
+			wd.Emit("dup", "seven")
+		case linkers[10].Node().Id():
+			wd.Emit(graph.OutputName, 10)
+		case linkers[9].Node().Id():
+			if v, err := graph.InputAs[int](wd, graph.InputName); err != nil || v != 10 {
+				t.Fatalf("Expected %v, got %v (%v)\n", 10, v, err)
+			}
+
+			if v, err := graph.InputAs[string](wd, "aux"); err != nil || v != "seven" {
+				t.Fatalf("Expected %v, got %v (%v)\n", "seven", v, err)
+			}
+
+			if _, err := graph.InputAs[string](wd, graph.InputName); err == nil {
+				t.Fatalf("Expected a type error\n")
+			}
+		case linkers[11].Node().Id():
+			if _, err := graph.InputAs[int](wd, graph.InputName); !errors.Is(err, graph.ErrMissingInput) {
+				t.Fatalf("Expected %v, got %v\n", graph.ErrMissingInput, err)
+			}
+		case linkers[8].Node().Id():
+			if v, ok := wd.Input(graph.InputName); !ok || v != 7 {
+				t.Fatalf("Expected %v, got %v\n", 7, v)
+			}
+		}
+
+		wd.Close()
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+}
+
+func TestWalkerInputOutput(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalker(linkers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	w.SetInput(linkers[0].Node(), graph.InputName, 5)
+	w.SetInput(linkers[1].Node(), graph.InputName, 6)
+
+	walker, errc := w.WalkContext(context.Background())
+
+	for wd := range walker {
+		switch wd.Node.Id() {
+		case linkers[0].Node().Id():
+			v, err := graph.InputAs[int](wd, graph.InputName)
+			if err != nil || v != 5 {
+				t.Fatalf("Expected %v, got %v (%v)\n", 5, v, err)
+			}
+
+			wd.Emit(graph.OutputName, v*2)
+		case linkers[1].Node().Id():
+			if v, ok := wd.Input(graph.InputName); !ok || v != 10 {
+				t.Fatalf("Expected %v, got %v\n", 10, v)
+			}
+		}
+
+		wd.Close()
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if v, ok := w.Output(linkers[0].Node(), graph.OutputName); !ok || v != 10 {
+		t.Fatalf("Expected %v, got %v\n", 10, v)
+	}
+}
+
+func TestWalkerInputOutsideParent(t *testing.T) {
+	a, b := base.NewLinker(), base.NewLinker()
+	a.Link(b)
+
+	w, err := graph.NewWalker(b)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	w.SetInput(b.Node(), graph.InputName, 42)
+
+	for wd := range w.Walk() {
+		if v, ok := wd.Input(graph.InputName); !ok || v != 42 {
+			t.Fatalf("Expected %v, got %v\n", 42, v)
+		}
+
+		wd.Close()
+	}
+}
+
+func TestWalkerFanOut(t *testing.T) {
+	load := base.NewLinker()
+
+	var branches []graph.Linker
+	for i := 0; i < 3; i++ {
+		l := base.NewLinker()
+		load.Link(l)
+
+		branches = append(branches, l)
+	}
+
+	save := base.NewLinker()
+	branches[0].Link(save)
+
+	w, err := graph.NewWalker(load)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := 5
+	if w.Total() != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, w.Total())
+	}
+
+	v := graph.NewVisitor()
+	for wd := range w.Walk() {
+		if wd.Node.Id() != load.Node().Id() && !v.Visited(load.Node()) {
+			t.Fatalf("Node %v depends on the load node\n", wd.Node)
+		}
+
+		v.Add(wd.Node)
+		wd.Close()
+	}
+
+	for _, l := range append(branches, save) {
+		if !v.Visited(l.Node()) {
+			t.Fatalf("Node %v should have been walked\n", l.Node())
+		}
+	}
+}
+
 func setupGraph() []graph.Linker {
 	linkers := make([]graph.Linker, 12)
 
@@ -137,3 +401,80 @@ func setupGraph() []graph.Linker {
 
 	return linkers
 }
+
+func TestMultiWalker(t *testing.T) {
+	linkers := setupGraph()
+
+	l1, l2 := base.NewLinker(), base.NewLinker()
+	l1.Link(l2)
+
+	w, err := graph.NewMultiWalker(linkers[0], l1, linkers[5])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expectedInt := len(linkers) + 2
+	if w.Total() != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, w.Total())
+	}
+
+	expectedInt = 5
+	if len(w.RootNodes()) != expectedInt {
+		t.Fatalf("Expected %v, got %v\n", expectedInt, len(w.RootNodes()))
+	}
+
+	v := graph.NewVisitor()
+	for wd := range w.Walk() {
+		if !v.Add(wd.Node) {
+			t.Fatalf("Node %#v should be new\n", wd.Node)
+		}
+
+		wd.Close()
+	}
+
+	for _, l := range append(linkers, l1, l2) {
+		if !v.Visited(l.Node()) {
+			t.Fatalf("Node %v should have been walked\n", l.Node())
+		}
+	}
+
+	if _, err := graph.NewMultiWalker(l1, base.NewLinkerNode(l2.Node())); !errors.Is(err, graph.ErrDuplicateId) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrDuplicateId, err)
+	}
+}
+
+func TestWalkerFor(t *testing.T) {
+	linkers := setupGraph()
+
+	w, err := graph.NewWalkerFor(linkers[9])
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 9, 10}
+	if w.Total() != len(expected) {
+		t.Fatalf("Expected %v, got %v\n", len(expected), w.Total())
+	}
+
+	v := graph.NewVisitor()
+	for wd := range w.Walk() {
+		if wd.Node.Id() == linkers[9].Node().Id() && !v.Visited(linkers[7].Node()) {
+			t.Fatalf("Node 9 depends on 7")
+		}
+
+		v.Add(wd.Node)
+		wd.Close()
+	}
+
+	for _, i := range expected {
+		if !v.Visited(linkers[i].Node()) {
+			t.Fatalf("Node %d should have been walked\n", i)
+		}
+	}
+
+	for _, i := range []int{8, 11} {
+		if v.Visited(linkers[i].Node()) {
+			t.Fatalf("Node %d shouldn't have been walked\n", i)
+		}
+	}
+}
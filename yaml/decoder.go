@@ -0,0 +1,342 @@
+// Package yaml reads graph documents written in YAML. Each YAML document
+// within the input is a separate graph root, described in the same way as
+// the json roots read by graph.ProcessJSON. Comments, anchors, aliases and
+// merge keys may be used to avoid repetition. As defined by the YAML
+// specification, an alias may only refer to an anchor of its own document.
+//
+//	# The edge detection pipeline
+//	name: Load
+//	options:
+//	  path: /tmp/in.png
+//	outputs:
+//	  Output:
+//	    name: Save
+//	    options:
+//	      path: /tmp/out.png
+//
+// The decoder is used with graph.ProcessDocument, or with the ProcessDocument
+// method of a graph.Registry.
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/urandom/graph"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Decoder reads the roots of a YAML graph document
+type Decoder struct {
+	dec *yamlv3.Decoder
+}
+
+type position struct {
+	line, column int
+}
+
+// converter converts a YAML node into json, recording the position of every
+// value by its lower-cased json pointer
+type converter struct {
+	positions map[string]position
+	// members holds the nodes of the document, without expanding aliases
+	members map[*yamlv3.Node]bool
+	// expanding holds the anchored nodes whose aliases are being expanded
+	expanding map[*yamlv3.Node]bool
+	// nodes is the number of converted nodes, which may not exceed the limit
+	nodes, limit int
+}
+
+// An alias may be expanded many times, and the converted document may
+// therefore grow exponentially. The number of converted nodes is limited to
+// a multiple of the nodes of the document, with an allowance for small
+// documents
+const (
+	expansionRatio     = 100
+	expansionAllowance = 100000
+)
+
+var (
+	ErrRecursiveAlias = errors.New("The alias refers to an anchor that contains it")
+	ErrExpansionLimit = errors.New("The document expands into too many nodes")
+	ErrForeignAlias   = errors.New("The alias refers to an anchor of another document")
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// NewDecoder creates a decoder that reads from the given reader
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: yamlv3.NewDecoder(r)}
+}
+
+// Decode returns the next YAML document of the input as a graph root, or
+// io.EOF if there are no more documents
+func (d *Decoder) Decode() (graph.DocumentRoot, error) {
+	var n yamlv3.Node
+	if err := d.dec.Decode(&n); err != nil {
+		if err == io.EOF {
+			return graph.DocumentRoot{}, err
+		}
+
+		derr := &graph.DocumentError{Err: fmt.Errorf("decoding root: %w", err)}
+		fmt.Sscanf(err.Error(), "yaml: line %d:", &derr.Line)
+
+		return graph.DocumentRoot{}, derr
+	}
+
+	members := make(map[*yamlv3.Node]bool)
+	collectNodes(&n, members)
+
+	c := &converter{positions: make(map[string]position), members: members,
+		expanding: make(map[*yamlv3.Node]bool),
+		limit:     expansionRatio*len(members) + expansionAllowance}
+
+	var b bytes.Buffer
+	if err := c.convert(&b, &n, ""); err != nil {
+		var derr *graph.DocumentError
+		if errors.As(err, &derr) {
+			return graph.DocumentRoot{}, derr
+		}
+
+		return graph.DocumentRoot{}, &graph.DocumentError{Line: n.Line, Column: n.Column, Err: err}
+	}
+
+	return graph.DocumentRoot{JSON: b.Bytes(), Position: c.position}, nil
+}
+
+func (c *converter) convert(b *bytes.Buffer, n *yamlv3.Node, path string) error {
+	c.record(path, n)
+
+	if err := c.count(n, path); err != nil {
+		return err
+	}
+
+	switch n.Kind {
+	case yamlv3.DocumentNode:
+		if len(n.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+
+		return c.convert(b, n.Content[0], path)
+	case yamlv3.AliasNode:
+		target, err := c.enter(n, path)
+		if err != nil {
+			return err
+		}
+		defer c.leave(n)
+
+		return c.convert(b, target, path)
+	case yamlv3.SequenceNode:
+		b.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			if err := c.convert(b, item, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case yamlv3.MappingNode:
+		keys, values, err := c.mappingPairs(n, path)
+		if err != nil {
+			return err
+		}
+
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			data, err := json.Marshal(key.Value)
+			if err != nil {
+				return err
+			}
+			b.Write(data)
+			b.WriteByte(':')
+
+			child := path + "/" + pointerEscaper.Replace(key.Value)
+			c.record(child, key)
+
+			if err := c.convert(b, values[i], child); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case yamlv3.ScalarNode:
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("line %d, column %d: %v", n.Line, n.Column, err)
+		}
+		b.Write(data)
+	default:
+		return fmt.Errorf("line %d, column %d: unsupported yaml node", n.Line, n.Column)
+	}
+
+	return nil
+}
+
+// record sets the position of the value with the given path, unless it is
+// already known. The position of a mapping value is the one of its key
+func (c *converter) record(path string, n *yamlv3.Node) {
+	path = strings.ToLower(path)
+	if _, ok := c.positions[path]; !ok {
+		c.positions[path] = position{line: n.Line, column: n.Column}
+	}
+}
+
+// position returns the position of the value with the given path, or of its
+// closest known ancestor
+func (c *converter) position(path string) (line, column int) {
+	path = strings.ToLower(path)
+	for {
+		if p, ok := c.positions[path]; ok {
+			return p.line, p.column
+		}
+
+		i := strings.LastIndexByte(path, '/')
+		if i == -1 {
+			return 0, 0
+		}
+		path = path[:i]
+	}
+}
+
+// count counts the node towards the limit of the converted nodes
+func (c *converter) count(n *yamlv3.Node, path string) error {
+	if c.nodes++; c.nodes > c.limit {
+		return &graph.DocumentError{Path: path, Line: n.Line, Column: n.Column, Err: ErrExpansionLimit}
+	}
+
+	return nil
+}
+
+// enter marks the anchored node of the alias as being expanded, returning
+// it. An error is returned if the node belongs to another document, or if it
+// is already being expanded, since the alias is a part of its own anchor
+func (c *converter) enter(alias *yamlv3.Node, path string) (*yamlv3.Node, error) {
+	if !c.members[alias.Alias] {
+		return nil, &graph.DocumentError{Path: path, Line: alias.Line, Column: alias.Column,
+			Err: fmt.Errorf("*%s: %w", alias.Value, ErrForeignAlias)}
+	}
+
+	if c.expanding[alias.Alias] {
+		return nil, &graph.DocumentError{Path: path, Line: alias.Line, Column: alias.Column,
+			Err: fmt.Errorf("*%s: %w", alias.Value, ErrRecursiveAlias)}
+	}
+
+	c.expanding[alias.Alias] = true
+	return alias.Alias, nil
+}
+
+func (c *converter) leave(alias *yamlv3.Node) {
+	delete(c.expanding, alias.Alias)
+}
+
+// mappingPairs returns the keys and values of a mapping node, including the
+// ones of any merged mappings. Explicit keys take precedence over merged
+// ones, and earlier merged mappings over later ones
+func (c *converter) mappingPairs(n *yamlv3.Node, path string) (keys, values []*yamlv3.Node, err error) {
+	seen := make(map[string]bool)
+	var merged []*yamlv3.Node
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Tag == "!!merge" {
+			merged = append(merged, value)
+			continue
+		}
+
+		seen[key.Value] = true
+		keys, values = append(keys, key), append(values, value)
+	}
+
+	for _, m := range merged {
+		if keys, values, err = c.merge(m, path, seen, keys, values); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return keys, values, nil
+}
+
+// merge appends the pairs of a merged mapping, or of a sequence of them, that
+// are not already seen
+func (c *converter) merge(
+	m *yamlv3.Node,
+	path string,
+	seen map[string]bool,
+	keys, values []*yamlv3.Node,
+) ([]*yamlv3.Node, []*yamlv3.Node, error) {
+	if m.Kind == yamlv3.AliasNode {
+		target, err := c.enter(m, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer c.leave(m)
+
+		m = target
+	}
+
+	sources := []*yamlv3.Node{m}
+	if m.Kind == yamlv3.SequenceNode {
+		sources = m.Content
+	}
+
+	for _, s := range sources {
+		// Merged mappings are never converted themselves, but they may
+		// still be expanded many times
+		if err := c.count(s, path); err != nil {
+			return nil, nil, err
+		}
+
+		if s.Kind == yamlv3.AliasNode {
+			var err error
+			if keys, values, err = c.merge(s, path, seen, keys, values); err != nil {
+				return nil, nil, err
+			}
+
+			continue
+		}
+
+		if s.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		mkeys, mvalues, err := c.mappingPairs(s, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i, key := range mkeys {
+			if !seen[key.Value] {
+				seen[key.Value] = true
+				keys, values = append(keys, key), append(values, mvalues[i])
+			}
+		}
+	}
+
+	return keys, values, nil
+}
+
+// collectNodes adds the node and all of its descendants to the set, without
+// expanding aliases
+func collectNodes(n *yamlv3.Node, nodes map[*yamlv3.Node]bool) {
+	nodes[n] = true
+	for _, child := range n.Content {
+		collectNodes(child, nodes)
+	}
+}
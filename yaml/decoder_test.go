@@ -0,0 +1,213 @@
+package yaml_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urandom/graph"
+	"github.com/urandom/graph/base"
+	"github.com/urandom/graph/yaml"
+)
+
+type namedNode struct {
+	graph.Node
+
+	name string
+	opts json.RawMessage
+}
+
+func (n namedNode) MarshalLinkerJSON() (string, json.RawMessage, error) {
+	return n.name, n.opts, nil
+}
+
+func newRegistry(t *testing.T) *graph.Registry {
+	r := graph.NewRegistry()
+
+	for _, name := range []string{"Load", "Save"} {
+		name := name
+		err := r.Register(name, func(opts json.RawMessage) (graph.Linker, error) {
+			l := base.NewLinkerNode(namedNode{Node: base.NewNode(), name: name, opts: opts})
+			l.InputConnectors["Aux"] = base.NewInputConnector("Aux")
+
+			return l, nil
+		}, graph.WithInputs(graph.InputName, "Aux"), graph.WithOutputs(graph.OutputName))
+		if err != nil {
+			t.Fatalf("Unexpected error %v\n", err)
+		}
+	}
+
+	return r
+}
+
+func TestDecoder(t *testing.T) {
+	r := newRegistry(t)
+
+	expected, err := r.ProcessJSON(testJSON, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	roots, err := r.ProcessDocument(yaml.NewDecoder(strings.NewReader(testYAML)))
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	e, err := graph.MarshalJSON(expected)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	a, err := graph.MarshalJSON(roots)
+	if err != nil {
+		t.Fatalf("Unexpected error %v\n", err)
+	}
+
+	if string(e) != string(a) {
+		t.Fatalf("Expected %s, got %s\n", e, a)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	r := newRegistry(t)
+
+	_, err := r.ProcessDocument(yaml.NewDecoder(strings.NewReader(testYAMLInvalid)))
+
+	var derr *graph.DocumentError
+	if !errors.As(err, &derr) {
+		t.Fatalf("Expected a document error, got %v\n", err)
+	}
+
+	if !errors.Is(err, graph.ErrUnknownConnector) {
+		t.Fatalf("Expected %v, got %v\n", graph.ErrUnknownConnector, err)
+	}
+
+	if derr.Path != "/1/outputs/Mask" || derr.Line != 5 || derr.Column != 3 {
+		t.Fatalf("Expected %v, got %v\n", "/1/outputs/Mask at 5:3", derr)
+	}
+
+	_, err = r.ProcessDocument(yaml.NewDecoder(strings.NewReader(testYAMLForeignAlias)))
+	if !errors.Is(err, yaml.ErrForeignAlias) || !errors.As(err, &derr) {
+		t.Fatalf("Expected %v, got %v\n", yaml.ErrForeignAlias, err)
+	}
+
+	if derr.Path != "/1/options" || derr.Line != 5 || derr.Column != 10 {
+		t.Fatalf("Expected %v, got %v\n", "/1/options at 5:10", derr)
+	}
+
+	_, err = r.ProcessDocument(yaml.NewDecoder(strings.NewReader("name: [Load\n")))
+	if !errors.As(err, &derr) || derr.Path != "/0" {
+		t.Fatalf("Expected a document error for /0, got %v\n", err)
+	}
+}
+
+func TestDecoderAliases(t *testing.T) {
+	cases := []struct {
+		input        string
+		err          error
+		line, column int
+	}{
+		{"name: Load\noptions: &x\n  self: *x\n", yaml.ErrRecursiveAlias, 3, 9},
+		{"name: Load\noptions: &x\n  <<: *x\n", yaml.ErrRecursiveAlias, 3, 7},
+		{testYAMLLaughs, yaml.ErrExpansionLimit, 0, 0},
+		{testYAMLMergeLaughs, yaml.ErrExpansionLimit, 0, 0},
+	}
+
+	for _, c := range cases {
+		_, err := yaml.NewDecoder(strings.NewReader(c.input)).Decode()
+
+		var derr *graph.DocumentError
+		if !errors.Is(err, c.err) || !errors.As(err, &derr) {
+			t.Fatalf("Expected %v, got %v\n", c.err, err)
+		}
+
+		if c.line != 0 && (derr.Line != c.line || derr.Column != c.column) {
+			t.Fatalf("Expected %v:%v, got %v\n", c.line, c.column, derr)
+		}
+	}
+}
+
+const (
+	testJSON = `
+{
+	"name": "Load",
+	"options": {"path": "in.png", "mode": "rgb", "quality": 90},
+	"outputs": {
+		"Output": [
+			{"name": "Save", "options": {"path": "out.png", "mode": "rgb", "quality": 90}, "referenceId": "save"},
+			{"name": "Save", "options": {"path": "out.jpg", "quality": 50, "mode": "rgb"}}
+		]
+	}
+}
+{
+	"name": "Load",
+	"options": {"path": "other.png", "mode": "rgb", "quality": 90},
+	"outputs": {"Output": {"referenceTo": "save", "input": "Aux"}}
+}`
+	testYAML = `
+# The common options
+name: Load
+options: &defaults
+  path: in.png
+  mode: rgb
+  quality: 90
+outputs:
+  Output:
+    - name: Save
+      options:
+        <<: *defaults
+        path: out.png
+      referenceId: save
+    - name: Save
+      options:
+        <<: *defaults
+        path: out.jpg
+        quality: 50
+---
+# Anchors are limited to the document that defines them
+name: Load
+options:
+  <<: &defaults {mode: rgb, quality: 90}
+  path: other.png
+outputs:
+  Output: {referenceTo: save, input: Aux}
+`
+	testYAMLInvalid = `name: Save
+---
+name: Load
+outputs:
+  Mask:
+    name: Save
+`
+	testYAMLForeignAlias = `name: Load
+options: &defaults {path: in.png}
+---
+name: Load
+options: *defaults
+`
+	testYAMLLaughs = `name: Load
+options:
+  a: &a [x, x, x, x, x, x, x, x, x, x]
+  b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]
+  c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]
+  d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]
+  e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]
+  f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e, *e]
+  g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f, *f]
+  h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g, *g]
+  i: [*h, *h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+	testYAMLMergeLaughs = `name: Load
+options:
+  a: &a {x: 1}
+  b: &b {<<: [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]}
+  c: &c {<<: [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]}
+  d: &d {<<: [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c]}
+  e: &e {<<: [*d, *d, *d, *d, *d, *d, *d, *d, *d, *d]}
+  f: &f {<<: [*e, *e, *e, *e, *e, *e, *e, *e, *e, *e]}
+  g: &g {<<: [*f, *f, *f, *f, *f, *f, *f, *f, *f, *f]}
+  h: &h {<<: [*g, *g, *g, *g, *g, *g, *g, *g, *g, *g]}
+  i: {<<: [*h, *h, *h, *h, *h, *h, *h, *h, *h, *h]}
+`
+)